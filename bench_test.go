@@ -0,0 +1,245 @@
+package lua
+
+import (
+	"fmt"
+	"testing"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+type benchTyped struct {
+	Typed
+	N    int
+	Name string
+}
+
+func (m *benchTyped) Add(a int, b int) int {
+	return a + b + m.N
+}
+
+var (
+	benchTypedType = &Type{
+		UUID: "0c6f8a1e-7f1d-4b8e-9d2a-5e3b7c9a1f40",
+		Name: "BenchTyped",
+		Type: GoType((*benchTyped)(nil)),
+	}
+)
+
+func benchMap() map[string]int {
+
+	var (
+		values = make(map[string]int, 256)
+	)
+
+	for index := 0; index < 256; index++ {
+		values[fmt.Sprintf("key%d", index)] = index
+	}
+
+	return values
+}
+
+func BenchmarkEncodeMap(b *testing.B) {
+
+	var (
+		vm      = New()
+		values  = benchMap()
+		encoder = NewEncoder(vm, FlagSkipMethod)
+	)
+
+	defer Close(vm)
+
+	b.ReportAllocs()
+
+	for index := 0; index < b.N; index++ {
+		if _, err := encoder.Encode(values); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeMap(b *testing.B) {
+
+	var (
+		vm = New()
+	)
+
+	defer Close(vm)
+
+	value, err := NewEncoder(vm, FlagSkipMethod).Encode(benchMap())
+
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for index := 0; index < b.N; index++ {
+
+		var (
+			values map[string]int
+		)
+
+		if err := NewDeocder(vm, FlagSkipMethod).Decode(value, &values); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncodeTyped(b *testing.B) {
+
+	var (
+		vm = New()
+	)
+
+	defer Close(vm)
+
+	Define(vm, benchTypedType)
+
+	b.ReportAllocs()
+
+	for index := 0; index < b.N; index++ {
+		if _, err := NewEncoder(vm, FlagTyped).Encode(&benchTyped{N: index}); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeTyped(b *testing.B) {
+
+	var (
+		vm = New()
+	)
+
+	defer Close(vm)
+
+	Define(vm, benchTypedType)
+
+	value, err := NewEncoder(vm, FlagTyped).Encode(&benchTyped{N: 1})
+
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for index := 0; index < b.N; index++ {
+
+		var (
+			x *benchTyped
+		)
+
+		if err := NewDeocder(vm, 0).Decode(value, &x); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchLoop(b *testing.B, vm *VM, body string) {
+
+	err := vm.DoString(`function bench(n) for i = 1, n do ` + body + ` end end`)
+
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	err = vm.CallByParam(lua.P{
+		Fn:      vm.GetGlobal("bench"),
+		Protect: true,
+	}, Number(b.N))
+
+	if err != nil {
+		b.Fatal(err)
+	}
+}
+
+func BenchmarkInvoker(b *testing.B) {
+
+	var (
+		vm = New()
+	)
+
+	defer Close(vm)
+
+	vm.SetGlobal("add", vm.NewFunction(VMGFunction(&Invoker{
+		Name: "add",
+		GoFunc: func(a int, b int) int {
+			return a + b
+		},
+	})))
+
+	benchLoop(b, vm, `add(i, 1)`)
+}
+
+func BenchmarkTypedMethod(b *testing.B) {
+
+	var (
+		vm = New()
+	)
+
+	defer Close(vm)
+
+	Define(vm, benchTypedType)
+
+	value, err := NewEncoder(vm, FlagTyped).Encode(&benchTyped{N: 1})
+
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	vm.SetGlobal("obj", value)
+
+	benchLoop(b, vm, `obj:Add(i, 1)`)
+}
+
+func BenchmarkTypedSetter(b *testing.B) {
+
+	var (
+		vm = New()
+	)
+
+	defer Close(vm)
+
+	Define(vm, benchTypedType)
+
+	value, err := NewEncoder(vm, FlagTyped).Encode(&benchTyped{N: 1})
+
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	vm.SetGlobal("obj", value)
+
+	benchLoop(b, vm, `obj.N = i`)
+}
+
+func BenchmarkCallback(b *testing.B) {
+
+	var (
+		vm  = New()
+		add func(int, int) (int, error)
+	)
+
+	defer Close(vm)
+
+	if err := vm.DoString(`function add(a, b) return a + b end`); err != nil {
+		b.Fatal(err)
+	}
+
+	if err := As(vm, vm.GetGlobal("add"), &add); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for index := 0; index < b.N; index++ {
+		if _, err := add(index, 1); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
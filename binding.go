@@ -0,0 +1,147 @@
+package lua
+
+import (
+	R "reflect"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// binding encodes a TableMapping pointer as an empty proxy table, field reads
+// and writes from lua go straight to the go struct behind src. The proxy
+// holds no keys, pairs, next and # see an empty table, gopher-lua has no
+// __pairs, scripts iterating over a struct need it encoded without
+// FlagLiveBinding.
+func (m *Encoder) binding(src R.Value, to *Value) error {
+
+	if m.checkNil(src, to) || m.lookup(src, to) {
+		return nil
+	}
+
+	var (
+		vm      = m.vm
+		ov      = src.Elem()
+		plan    = planOf(ov.Type())
+		members map[string]*Function
+		tbl     = vm.NewTable()
+		mt      = vm.NewTable()
+	)
+
+	if !m.skipMethod {
+		members = make(map[string]*Function)
+
+		for name, member := range memberFunctions(src, func(v R.Value, index int, i *Invoker) {
+			i.Self = true
+			i.Caller = func(vm *VM) (R.Value, error) {
+				return v.Method(index), nil
+			}
+		}) {
+			members[name] = vm.NewFunction(member)
+		}
+	}
+
+	field := func(name string) (R.Value, bool) {
+
+		fp, ok := plan.field(name)
+
+		if !ok {
+			return R.Value{}, false
+		}
+
+		return ov.Field(fp.index), true
+	}
+
+	index := func(vm *VM) int {
+
+		var (
+			name = vm.CheckString(2)
+		)
+
+		fv, ok := field(name)
+
+		if !ok {
+			if member, ok := members[name]; ok {
+				vm.Push(member)
+				return 1
+			}
+
+			vm.Push(Nil)
+			return 1
+		}
+
+		var (
+			encoder = NewEncoder(vm, FlagLiveBinding)
+		)
+
+		encoder.skipMethod = m.skipMethod
+
+		value, err := encoder.Encode(fv)
+
+		if err != nil {
+			vm.RaiseError("%s", err)
+			return 0
+		}
+
+		vm.Push(value)
+		return 1
+	}
+
+	newindex := func(vm *VM) int {
+
+		var (
+			name  = vm.CheckString(2)
+			value = vm.Get(3)
+		)
+
+		fv, ok := field(name)
+
+		if !ok {
+			vm.ArgError(2, "field "+name+" not found")
+			return 0
+		}
+
+		var (
+			decoder = NewDeocder(vm, FlagSkipMethod)
+		)
+
+		if err := decoder.decode(value, fv, name); err != nil {
+			vm.ArgError(3, err.Error())
+		}
+
+		return 0
+	}
+
+	vm.SetField(mt, "__index", vm.NewFunction(func(vm *VM) int {
+		return protect(vm, "__index", func() int {
+			return index(vm)
+		})
+	}))
+
+	vm.SetField(mt, "__newindex", vm.NewFunction(func(vm *VM) int {
+		return protect(vm, "__newindex", func() int {
+			return newindex(vm)
+		})
+	}))
+
+	vm.SetMetatable(tbl, mt)
+	m.remember(src, tbl)
+
+	*to = tbl
+
+	return nil
+}
+
+// SyncBack decodes a table produced from a TableMapping back into the original
+// go pointer, so changes made by a script are visible to go. Fields missing
+// in the table are reset to their zero value.
+func SyncBack(vm *VM, src Value, to interface{}) error {
+
+	if src.Type() != lua.LTTable {
+		return errTypeConvert{"", src.Type(), R.TypeOf(to)}
+	}
+
+	var (
+		decoder = NewDeocder(vm, FlagSkipMethod|FlagZeroMissing)
+	)
+
+	return decoder.Decode(src, to)
+}
@@ -0,0 +1,98 @@
+package lua
+
+import (
+	"testing"
+)
+
+type bindingPoint struct {
+	TableMapping
+	X    int
+	Y    int
+	Name string
+}
+
+func TestBindingWriteThrough(t *testing.T) {
+
+	var (
+		vm = New()
+		p  = &bindingPoint{X: 1, Y: 2, Name: "a"}
+	)
+
+	defer Close(vm)
+
+	value, err := NewEncoder(vm, FlagLiveBinding).Encode(p)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vm.SetGlobal("p", value)
+
+	err = vm.DoString(`
+		assert(p.X == 1 and p.Name == "a")
+		p.X = 10
+		p.Name = "b"
+		assert(p.X == 10 and p.Y == 2)
+		assert(next(p) == nil and #p == 0, "the proxy should hold no keys")
+	`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.X != 10 || p.Y != 2 || p.Name != "b" {
+		t.Fatalf("lua writes not seen by go: %+v", *p)
+	}
+
+	p.Y = 20
+
+	if err := vm.DoString(`assert(p.Y == 20)`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := vm.DoString(`p.Z = 1`); err == nil {
+		t.Fatal("writing a missing field should fail")
+	}
+
+	if err := vm.DoString(`p.X = "x"`); err == nil {
+		t.Fatal("writing a field of another type should fail")
+	}
+}
+
+func TestSyncBackZeroMissing(t *testing.T) {
+
+	var (
+		vm = New()
+		p  = &bindingPoint{X: 1, Y: 2, Name: "a"}
+	)
+
+	defer Close(vm)
+
+	value, err := NewEncoder(vm, 0).Encode(p)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vm.SetGlobal("p", value)
+
+	if err := vm.DoString(`p.X = 5; p.Name = nil`); err != nil {
+		t.Fatal(err)
+	}
+
+	if p.X != 1 {
+		t.Fatal("a plain table should not write through")
+	}
+
+	if err := SyncBack(vm, vm.GetGlobal("p"), p); err != nil {
+		t.Fatal(err)
+	}
+
+	if p.X != 5 || p.Y != 2 || p.Name != "" {
+		t.Fatalf("sync back gave %+v", *p)
+	}
+
+	if err := SyncBack(vm, Number(1), p); err == nil {
+		t.Fatal("sync back from a number should fail")
+	}
+}
@@ -1,14 +1,20 @@
 package lua
 
 import (
+	"context"
 	"fmt"
 	R "reflect"
+
+	lua "github.com/yuin/gopher-lua"
 )
 
 type Call struct {
-	vm   *VM
-	name string
-	Args []Value
+	vm         *VM
+	name       string
+	base       int
+	convention ReturnConvention
+	protect    bool
+	Args       []Value
 }
 
 func (m *Call) ArgError(n int, format string, v ...interface{}) int {
@@ -17,6 +23,189 @@ func (m *Call) ArgError(n int, format string, v ...interface{}) int {
 	return 0
 }
 
+func (m *Call) Name() string {
+	return m.name
+}
+
+func (m *Call) VM() *VM {
+	return m.vm
+}
+
+func (m *Call) Context() context.Context {
+
+	if ctx := m.vm.Context(); ctx != nil {
+		return ctx
+	}
+
+	return context.Background()
+}
+
+func (m *Call) Upvalue(n int) Value {
+	return m.vm.Get(lua.UpvalueIndex(n))
+}
+
+func (m *Call) NArgs() int {
+	return len(m.Args)
+}
+
+func (m *Call) CheckArgs(min int, max int) {
+
+	var (
+		given = len(m.Args)
+	)
+
+	if given < min {
+		m.vm.ArgError(m.base+given, errArgCount{min, max, max < 0, given}.Error())
+	}
+
+	if max >= 0 && given > max {
+		m.vm.ArgError(m.base+max, errArgCount{min, max, false, given}.Error())
+	}
+}
+
+// Arg returns the n-th argument counted from 1, Nil if it was not given.
+func (m *Call) Arg(n int) Value {
+
+	if n < 1 || n > len(m.Args) {
+		return Nil
+	}
+
+	return m.Args[n-1]
+}
+
+func (m *Call) check(n int, to interface{}) {
+
+	var (
+		v    = R.ValueOf(to).Elem()
+		lv   = m.Arg(n)
+		opts = &asOptions{
+			vm:       m.vm,
+			field:    fmt.Sprintf("%s(#%d)", m.name, n),
+			skipFunc: false,
+			base:     Nil,
+			optional: false,
+			raise:    false,
+		}
+	)
+
+	if goValue(m.vm, v, lv, opts) && opts.Ok() {
+		return
+	}
+
+	m.vm.ArgError(
+		m.base+n-1,
+		opts.GetError(
+			errTypeConvert{
+				opts.field,
+				lv.Type(),
+				v.Type(),
+			}).Error())
+}
+
+func (m *Call) Decode(n int, v interface{}) {
+
+	if R.TypeOf(v).Kind() != R.Ptr {
+		panic("call decode value must be a pointer")
+	}
+
+	m.check(n, v)
+}
+
+func (m *Call) String(n int) (v string) {
+
+	if x, ok := m.Arg(n).(String); ok {
+		return string(x)
+	}
+
+	m.check(n, &v)
+	return
+}
+
+func (m *Call) OptString(n int, d string) string {
+
+	if m.Arg(n) == Nil {
+		return d
+	}
+
+	return m.String(n)
+}
+
+func (m *Call) Int(n int) (v int) {
+
+	if x, ok := m.Arg(n).(Number); ok {
+		return int(x)
+	}
+
+	m.check(n, &v)
+	return
+}
+
+func (m *Call) OptInt(n int, d int) int {
+
+	if m.Arg(n) == Nil {
+		return d
+	}
+
+	return m.Int(n)
+}
+
+func (m *Call) Number(n int) (v float64) {
+
+	if x, ok := m.Arg(n).(Number); ok {
+		return float64(x)
+	}
+
+	m.check(n, &v)
+	return
+}
+
+func (m *Call) OptNumber(n int, d float64) float64 {
+
+	if m.Arg(n) == Nil {
+		return d
+	}
+
+	return m.Number(n)
+}
+
+func (m *Call) Bool(n int) (v bool) {
+
+	if x, ok := m.Arg(n).(Bool); ok {
+		return bool(x)
+	}
+
+	m.check(n, &v)
+	return
+}
+
+func (m *Call) OptBool(n int, d bool) bool {
+
+	if m.Arg(n) == Nil {
+		return d
+	}
+
+	return m.Bool(n)
+}
+
+func (m *Call) Table(n int) (v *Table) {
+	m.check(n, &v)
+	return
+}
+
+func (m *Call) OptTable(n int, d *Table) *Table {
+
+	if m.Arg(n) == Nil {
+		return d
+	}
+
+	return m.Table(n)
+}
+
+func (m *Call) Function(n int) (v *Function) {
+	m.check(n, &v)
+	return
+}
+
 func (m *Call) Push(values ...Value) int {
 
 	for _, value := range values {
@@ -71,16 +260,17 @@ func (m *Call) Return(args ...interface{}) int {
 	return m.Push(values...)
 }
 
-func newCall(vm *VM, name string) *Call {
+func newCall(vm *VM, name string, base int) *Call {
 
 	c := &Call{
 		vm:   vm,
 		name: name,
+		base: base,
 		Args: make([]Value, 0),
 	}
 
-	for index := 1; index < vm.GetTop(); index++ {
-		c.Args = append(c.Args, vm.Get(index+1))
+	for index := base; index <= vm.GetTop(); index++ {
+		c.Args = append(c.Args, vm.Get(index))
 	}
 
 	return c
@@ -0,0 +1,80 @@
+package lua
+
+import (
+	"strings"
+	"testing"
+)
+
+type callerPoint struct {
+	TableMapping
+	X int
+	Y int
+}
+
+func TestCallHelpers(t *testing.T) {
+
+	var (
+		vm = New()
+	)
+
+	defer Close(vm)
+
+	describe := VMGFunction(&Invoker{
+		Name: "describe",
+		GoFunc: func(c *Call) int {
+
+			c.CheckArgs(2, 6)
+
+			var (
+				p callerPoint
+			)
+
+			c.Decode(2, &p)
+
+			return c.Return(
+				c.Name(),
+				c.String(1),
+				c.OptInt(3, 7),
+				c.OptNumber(4, 0.5),
+				c.OptBool(5, true),
+				p.X+p.Y,
+				c.NArgs(),
+				c.Upvalue(1),
+			)
+		},
+	})
+
+	vm.SetGlobal("describe", vm.NewClosure(describe, String("up")))
+
+	tests := []struct {
+		script string
+		err    string
+	}{
+		{`
+			local name, s, i, n, b, sum, nargs, up = describe("a", {X = 1, Y = 2})
+			assert(name == "describe" and s == "a" and i == 7 and n == 0.5 and b == true)
+			assert(sum == 3 and nargs == 2 and up == "up")
+		`, ""},
+		{`
+			local _, s, i, n, b = describe(1, {X = 0, Y = 0}, 3, 1.5, false)
+			assert(s == "1" and i == 3 and n == 1.5 and b == false)
+		`, ""},
+		{`describe("a")`, "want 2 to 6 arguments, given 1"},
+		{`describe("a", {X = 0, Y = 0}, 1, 2, true, 6, 7)`, "want 2 to 6 arguments, given 7"},
+		{`describe({}, {X = 0, Y = 0})`, "bad argument #1"},
+		{`describe("a", {X = "x", Y = 0})`, "bad argument #2"},
+		{`describe("a", {X = 0, Y = 0}, "x")`, "bad argument #3"},
+	}
+
+	for _, test := range tests {
+
+		err := vm.DoString(test.script)
+
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("%s: %s", test.script, err)
+		case test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)):
+			t.Errorf("%s: want error %q, got %v", test.script, test.err, err)
+		}
+	}
+}
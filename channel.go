@@ -0,0 +1,297 @@
+package lua
+
+import (
+	R "reflect"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// bridges are kept per vm, so a channel handed to lua twice is still read
+// by one goroutine only. They stop once stop is closed by Close.
+func (m *lifecycle) bridge(ref reference, create func(stop <-chan struct{}) R.Value) R.Value {
+
+	if m.bridges == nil {
+		m.bridges = make(map[reference]R.Value)
+		m.stop = make(chan struct{})
+	}
+
+	if v, ok := m.bridges[ref]; ok {
+		return v
+	}
+
+	v := create(m.stop)
+	m.bridges[ref] = v
+
+	return v
+}
+
+// element tells if values of t can cross a bridge, they are converted on the
+// bridging goroutine which must not touch the vm, so only plain values are
+// allowed.
+func element(t R.Type) bool {
+
+	switch t.Kind() {
+	case R.Bool, R.String, R.Float32, R.Float64,
+		R.Int, R.Int8, R.Int16, R.Int32, R.Int64,
+		R.Uint, R.Uint8, R.Uint16, R.Uint32, R.Uint64:
+		return true
+	}
+
+	return false
+}
+
+func encodeElement(x R.Value) (Value, bool) {
+
+	switch x.Kind() {
+	case R.Bool:
+		return Bool(x.Bool()), true
+	case R.String:
+		return String(x.String()), true
+	case R.Float32, R.Float64:
+		return Number(x.Float()), true
+	case R.Int, R.Int8, R.Int16, R.Int32, R.Int64:
+		return Number(x.Int()), true
+	case R.Uint, R.Uint8, R.Uint16, R.Uint32, R.Uint64:
+		return Number(x.Uint()), true
+	}
+
+	return Nil, false
+}
+
+func decodeElement(value Value, t R.Type) (R.Value, bool) {
+
+	var (
+		x = R.New(t).Elem()
+	)
+
+	if value == Nil {
+		return x, true
+	}
+
+	switch v := value.(type) {
+	case Bool:
+		if t.Kind() == R.Bool {
+			x.SetBool(bool(v))
+			return x, true
+		}
+	case String:
+		if t.Kind() == R.String {
+			x.SetString(string(v))
+			return x, true
+		}
+	case Number:
+		switch t.Kind() {
+		case R.Float32, R.Float64:
+			x.SetFloat(float64(v))
+			return x, true
+		case R.Int, R.Int8, R.Int16, R.Int32, R.Int64:
+			if float64(v) == float64(int64(v)) && !x.OverflowInt(int64(v)) {
+				x.SetInt(int64(v))
+				return x, true
+			}
+		case R.Uint, R.Uint8, R.Uint16, R.Uint32, R.Uint64:
+			if v >= 0 && float64(v) == float64(uint64(v)) && !x.OverflowUint(uint64(v)) {
+				x.SetUint(uint64(v))
+				return x, true
+			}
+		}
+	}
+
+	return x, false
+}
+
+// receive reports false when ch is closed or the bridge is stopped.
+func receive(stop <-chan struct{}, ch R.Value) (R.Value, bool) {
+
+	chosen, x, ok := R.Select([]R.SelectCase{
+		{Dir: R.SelectRecv, Chan: R.ValueOf(stop)},
+		{Dir: R.SelectRecv, Chan: ch},
+	})
+
+	return x, chosen == 1 && ok
+}
+
+// send reports false instead of panicking when the peer closed ch, or when
+// the bridge is stopped.
+func send(stop <-chan struct{}, ch, x R.Value) (ok bool) {
+
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+
+	chosen, _, _ := R.Select([]R.SelectCase{
+		{Dir: R.SelectRecv, Chan: R.ValueOf(stop)},
+		{Dir: R.SelectSend, Chan: ch, Send: x},
+	})
+
+	return chosen == 1
+}
+
+func closeChannel(ch R.Value) {
+
+	defer func() {
+		recover()
+	}()
+
+	ch.Close()
+}
+
+// drain drops the values of ch until it is closed or the bridge is stopped,
+// so the senders of a failed bridge do not block.
+func drain(stop <-chan struct{}, ch R.Value) {
+
+	for {
+		if _, ok := receive(stop, ch); !ok {
+			return
+		}
+	}
+}
+
+// luaChannel bridges a go channel to a lua channel. Values of a receive-only
+// go channel are forwarded to lua, a send-only go channel receives the values
+// lua sends, a bidirectional one is refused as its direction is unknown.
+// Elements must be bools, numbers or strings, they are converted on the
+// bridging goroutine.
+//
+// The bridge only closes the channel it created and is the sole sender of,
+// closing any other one would race with its senders. When the channel it
+// sends to is closed by its peer, or a value can not be converted, the
+// bridge stops: the channel it sends to is closed if it created it, and the
+// values left in the channel it reads are dropped so their senders do not
+// block. Close stops the bridges of its vm.
+func luaChannel(vm *VM, src R.Value) (lua.LChannel, error) {
+
+	var (
+		t   = src.Type()
+		ref = reference{ptr: src.Pointer(), t: t}
+	)
+
+	if t.ChanDir() == R.BothDir {
+		return nil, &errorChannelDir{t}
+	}
+
+	if !element(t.Elem()) {
+		return nil, &errorChannel{t}
+	}
+
+	v := loadState(vm).bridge(ref, func(stop <-chan struct{}) R.Value {
+
+		var (
+			ch = R.ValueOf(make(lua.LChannel, src.Cap()))
+		)
+
+		if t.ChanDir() == R.RecvDir {
+			go func() {
+				defer closeChannel(ch)
+
+				for {
+					x, ok := receive(stop, src)
+
+					if !ok {
+						return
+					}
+
+					value, ok := encodeElement(x)
+
+					if !ok || !send(stop, ch, R.ValueOf(&value).Elem()) {
+						closeChannel(ch)
+						drain(stop, src)
+						return
+					}
+				}
+			}()
+		} else {
+			go func() {
+				for {
+					value, ok := receive(stop, ch)
+
+					if !ok {
+						return
+					}
+
+					x, ok := decodeElement(value.Interface().(Value), t.Elem())
+
+					if !ok || !send(stop, src, x) {
+						drain(stop, ch)
+						return
+					}
+				}
+			}()
+		}
+
+		return ch
+	})
+
+	return v.Interface().(lua.LChannel), nil
+}
+
+// goChannel bridges a lua channel to a go channel of type t, a receive-only
+// go channel receives the values sent from lua, values sent to a send-only go
+// channel are forwarded to lua. The bridge stops like the one of luaChannel.
+func goChannel(vm *VM, src lua.LChannel, t R.Type) (R.Value, error) {
+
+	var (
+		lv  = R.ValueOf(src)
+		ref = reference{ptr: lv.Pointer(), t: t}
+	)
+
+	if t.ChanDir() == R.BothDir {
+		return R.Value{}, &errorChannelDir{t}
+	}
+
+	if !element(t.Elem()) {
+		return R.Value{}, &errorChannel{t}
+	}
+
+	v := loadState(vm).bridge(ref, func(stop <-chan struct{}) R.Value {
+
+		var (
+			ch = R.MakeChan(R.ChanOf(R.BothDir, t.Elem()), cap(src))
+		)
+
+		if t.ChanDir() == R.RecvDir {
+			go func() {
+				defer closeChannel(ch)
+
+				for {
+					value, ok := receive(stop, lv)
+
+					if !ok {
+						return
+					}
+
+					x, ok := decodeElement(value.Interface().(Value), t.Elem())
+
+					if !ok || !send(stop, ch, x) {
+						closeChannel(ch)
+						drain(stop, lv)
+						return
+					}
+				}
+			}()
+		} else {
+			go func() {
+				for {
+					x, ok := receive(stop, ch)
+
+					if !ok {
+						return
+					}
+
+					value, ok := encodeElement(x)
+
+					if !ok || !send(stop, lv, R.ValueOf(&value).Elem()) {
+						drain(stop, ch)
+						return
+					}
+				}
+			}()
+		}
+
+		return ch
+	})
+
+	return v.Convert(t), nil
+}
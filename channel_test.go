@@ -0,0 +1,219 @@
+package lua
+
+import (
+	"testing"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+func within(t *testing.T, what string, fn func()) {
+
+	var (
+		done = make(chan struct{})
+	)
+
+	go func() {
+		defer close(done)
+		fn()
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("%s is blocked", what)
+	}
+}
+
+func TestChannelCloseFromLua(t *testing.T) {
+
+	var (
+		vm  = New()
+		src = make(chan int)
+		out chan<- int
+	)
+
+	defer Close(vm)
+
+	ch, err := NewEncoder(vm, 0).Encode((<-chan int)(src))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vm.SetGlobal("ch", ch)
+
+	if err := vm.DoString(`ch:close()`); err != nil {
+		t.Fatal(err)
+	}
+
+	within(t, "sending after lua closed", func() {
+		src <- 1
+		src <- 2
+	})
+
+	close(src)
+
+	if err := vm.DoString(`lch = channel.make()`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := As(vm, vm.GetGlobal("lch"), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := vm.DoString(`lch:close()`); err != nil {
+		t.Fatal(err)
+	}
+
+	within(t, "sending to a closed lua channel", func() {
+		out <- 1
+		out <- 2
+	})
+}
+
+func TestChannelCloseFromGo(t *testing.T) {
+
+	var (
+		vm  = New()
+		src = make(chan int)
+		dst = make(chan int)
+	)
+
+	defer Close(vm)
+
+	in, err := NewEncoder(vm, 0).Encode((<-chan int)(src))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := NewEncoder(vm, 0).Encode((chan<- int)(dst))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vm.SetGlobal("input", in)
+	vm.SetGlobal("output", out)
+
+	close(src)
+
+	if err := vm.DoString(`assert(not input:receive(), "input should be closed")`); err != nil {
+		t.Fatal(err)
+	}
+
+	within(t, "sending to go", func() {
+		if err := vm.DoString(`output:send(1)`); err != nil {
+			t.Error(err)
+		}
+	})
+
+	if x := <-dst; x != 1 {
+		t.Fatalf("go received %d", x)
+	}
+
+	close(dst)
+
+	within(t, "sending after go closed", func() {
+		if err := vm.DoString(`for i = 2, 10 do output:send(i) end`); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func TestChannelElements(t *testing.T) {
+
+	var (
+		vm = New()
+		in <-chan int
+	)
+
+	defer Close(vm)
+
+	if _, err := NewEncoder(vm, 0).Encode(make(<-chan []int)); err == nil {
+		t.Fatal("bridging a channel of slices should fail")
+	}
+
+	if err := NewDeocder(vm, 0).Decode(lua.LChannel(make(chan Value)), new(<-chan map[string]int)); err == nil {
+		t.Fatal("bridging a channel of maps should fail")
+	}
+
+	if err := vm.DoString(`lch = channel.make(1)`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := As(vm, vm.GetGlobal("lch"), &in); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := vm.DoString(`lch:send(1.5)`); err != nil {
+		t.Fatal(err)
+	}
+
+	within(t, "receiving an unconvertible value", func() {
+		if _, ok := <-in; ok {
+			t.Error("the bridge should close on a value it can not convert")
+		}
+	})
+}
+
+func TestChannelDirection(t *testing.T) {
+
+	var (
+		vm = New()
+	)
+
+	defer Close(vm)
+
+	if _, err := NewEncoder(vm, 0).Encode(make(chan int)); err == nil {
+		t.Fatal("bridging a bidirectional go channel should fail")
+	}
+
+	if err := NewDeocder(vm, 0).Decode(lua.LChannel(make(chan Value)), new(chan int)); err == nil {
+		t.Fatal("bridging to a bidirectional go channel should fail")
+	}
+}
+
+func TestChannelStopOnClose(t *testing.T) {
+
+	var (
+		vm  = New()
+		src = make(chan int)
+		in  <-chan int
+	)
+
+	value, err := NewEncoder(vm, 0).Encode((<-chan int)(src))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := vm.DoString(`lch = channel.make()`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := As(vm, vm.GetGlobal("lch"), &in); err != nil {
+		t.Fatal(err)
+	}
+
+	state := loadState(vm)
+
+	if err := Close(vm); err != nil {
+		t.Fatal(err)
+	}
+
+	if state.bridges != nil {
+		t.Fatal("bridges left after close")
+	}
+
+	within(t, "receiving from a stopped bridge", func() {
+		if _, ok := <-value.(lua.LChannel); ok {
+			t.Error("a stopped bridge should close the lua channel")
+		}
+
+		if _, ok := <-in; ok {
+			t.Error("a stopped bridge should close the go channel")
+		}
+	})
+}
@@ -0,0 +1,650 @@
+// Command luagen generates reflection-free lua bindings for the methods of
+// module members and Typed types, use it from a go:generate directive:
+//
+//	//go:generate go run github.com/mz-eco/lua/cmd/luagen -type Members,Time
+//
+// Members must embed lua.ModuleMembers and get a LuaFunctions method, Typed
+// types get a LuaMethods method, both get a LuaSignatures method for help. Parameters and results of builtin types are
+// converted inline, other types go through the reflective decoder and
+// encoder, so both paths behave the same.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	luaPath = "github.com/mz-eco/lua"
+)
+
+var (
+	typeNames = flag.String("type", "", "comma separated list of type names")
+	output    = flag.String("output", "", "output file name, default <type>_lua.go")
+)
+
+type kind int
+
+const (
+	kindMembers kind = iota
+	kindTyped
+)
+
+type target struct {
+	name    string
+	kind    kind
+	methods []*ast.FuncDecl
+}
+
+type generator struct {
+	pkg      string
+	selfType string
+	buf      bytes.Buffer
+	imports  map[string]string
+	files    map[*ast.FuncDecl]*ast.File
+}
+
+func (m *generator) printf(format string, args ...interface{}) {
+	fmt.Fprintf(&m.buf, format, args...)
+}
+
+// importName returns the name file uses for the package at path.
+func importName(file *ast.File, path string) string {
+
+	for _, spec := range file.Imports {
+
+		p, _ := strconv.Unquote(spec.Path.Value)
+
+		if p != path {
+			continue
+		}
+
+		if spec.Name != nil {
+			return spec.Name.Name
+		}
+
+		return filepath.Base(p)
+	}
+
+	return ""
+}
+
+// expr prints x as it is written in file, the lua package is renamed to lua
+// and the other packages it references are imported by the output.
+func (m *generator) expr(file *ast.File, x ast.Expr) string {
+
+	var (
+		luaName = importName(file, luaPath)
+	)
+
+	ast.Inspect(x, func(n ast.Node) bool {
+
+		sel, ok := n.(*ast.SelectorExpr)
+
+		if !ok {
+			return true
+		}
+
+		id, ok := sel.X.(*ast.Ident)
+
+		if !ok {
+			return true
+		}
+
+		if id.Name == luaName {
+			id.Name = "lua"
+			return false
+		}
+
+		for _, spec := range file.Imports {
+
+			p, _ := strconv.Unquote(spec.Path.Value)
+
+			if importName(file, p) != id.Name {
+				continue
+			}
+
+			if id.Name == "lua" {
+				log.Fatalf("package %s is imported as lua, rename it", p)
+			}
+
+			m.imports[p] = id.Name
+		}
+
+		return false
+	})
+
+	return types.ExprString(x)
+}
+
+func embeds(file *ast.File, st *ast.StructType, name string) bool {
+
+	var (
+		luaName = importName(file, luaPath)
+	)
+
+	for _, field := range st.Fields.List {
+
+		if len(field.Names) != 0 {
+			continue
+		}
+
+		if sel, ok := field.Type.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok && id.Name == luaName && sel.Sel.Name == name {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func receiver(fn *ast.FuncDecl) string {
+
+	if fn.Recv == nil || len(fn.Recv.List) != 1 {
+		return ""
+	}
+
+	var (
+		x = fn.Recv.List[0].Type
+	)
+
+	if star, ok := x.(*ast.StarExpr); ok {
+		x = star.X
+	}
+
+	if id, ok := x.(*ast.Ident); ok {
+		return id.Name
+	}
+
+	return ""
+}
+
+type param struct {
+	typ      string
+	name     string
+	variadic bool
+	pointer  bool
+}
+
+// typeName names x the way reflect does, types of the package are qualified
+// with its name.
+func (m *generator) typeName(x ast.Expr) string {
+
+	switch x := x.(type) {
+	case *ast.Ident:
+		if types.Universe.Lookup(x.Name) != nil {
+			return x.Name
+		}
+		return m.pkg + "." + x.Name
+	case *ast.StarExpr:
+		return "*" + m.typeName(x.X)
+	case *ast.ArrayType:
+		if x.Len == nil {
+			return "[]" + m.typeName(x.Elt)
+		}
+		return "[" + types.ExprString(x.Len) + "]" + m.typeName(x.Elt)
+	case *ast.MapType:
+		return "map[" + m.typeName(x.Key) + "]" + m.typeName(x.Value)
+	case *ast.InterfaceType:
+		if x.Methods == nil || len(x.Methods.List) == 0 {
+			return "interface {}"
+		}
+	}
+
+	return types.ExprString(x)
+}
+
+func (m *generator) params(file *ast.File, list *ast.FieldList) []param {
+
+	var (
+		params = make([]param, 0)
+	)
+
+	if list == nil {
+		return params
+	}
+
+	for _, field := range list.List {
+
+		var (
+			n = len(field.Names)
+			x = field.Type
+			p = param{}
+		)
+
+		if ellipsis, ok := x.(*ast.Ellipsis); ok {
+			p.variadic = true
+			x = ellipsis.Elt
+		}
+
+		_, p.pointer = x.(*ast.StarExpr)
+		p.typ = m.expr(file, x)
+		p.name = m.typeName(x)
+
+		if n == 0 {
+			n = 1
+		}
+
+		for index := 0; index < n; index++ {
+			params = append(params, p)
+		}
+	}
+
+	return params
+}
+
+var (
+	builtins = map[string]string{
+		"int":     "lua.Number",
+		"int8":    "lua.Number",
+		"int16":   "lua.Number",
+		"int32":   "lua.Number",
+		"int64":   "lua.Number",
+		"uint":    "lua.Number",
+		"uint8":   "lua.Number",
+		"uint16":  "lua.Number",
+		"uint32":  "lua.Number",
+		"uint64":  "lua.Number",
+		"float32": "lua.Number",
+		"float64": "lua.Number",
+		"string":  "lua.String",
+		"bool":    "lua.Bool",
+	}
+	// numbers go through the same intermediate type as in the decoder
+	widths = map[string]string{
+		"int":    "int64",
+		"int8":   "int64",
+		"int16":  "int64",
+		"int32":  "int64",
+		"uint":   "uint64",
+		"uint8":  "uint64",
+		"uint16": "uint64",
+		"uint32": "uint64",
+	}
+)
+
+// arg converts the n-th lua argument into the go variable name, a nil
+// argument leaves the zero value like the reflective invoker does. Lua values
+// of the matching type are converted inline, the others are left to the
+// reflective decoder so errors are reported the same way.
+func (m *generator) arg(name string, n string, p param) {
+
+	lt, ok := builtins[p.typ]
+
+	if !ok {
+		m.printf("if c.Arg(%s) != lua.Nil {\n", n)
+		m.printf("c.Decode(%s, &%s)\n", n, name)
+		m.printf("}\n")
+		return
+	}
+
+	m.printf("if x, ok := c.Arg(%s).(%s); ok {\n", n, lt)
+	if width, ok := widths[p.typ]; ok {
+		m.printf("%s = %s(%s(x))\n", name, p.typ, width)
+	} else {
+		m.printf("%s = %s(x)\n", name, p.typ)
+	}
+	m.printf("} else if c.Arg(%s) != lua.Nil {\n", n)
+	m.printf("c.Decode(%s, &%s)\n", n, name)
+	m.printf("}\n")
+}
+
+// signature describes fn like Invoker.Signature does, so help shows the
+// same for both bindings.
+func signature(name string, params []param, fixed int, required int, results []param) string {
+
+	var (
+		in  = make([]string, 0)
+		out = make([]string, 0)
+	)
+
+	for index, p := range params {
+		switch {
+		case index >= fixed:
+			in = append(in, "..."+p.name)
+		case index >= required:
+			in = append(in, "["+p.name+"]")
+		default:
+			in = append(in, p.name)
+		}
+	}
+
+	for _, p := range results {
+		out = append(out, p.name)
+	}
+
+	if len(out) == 0 {
+		return fmt.Sprintf("%s(%s)", name, strings.Join(in, ", "))
+	}
+
+	return fmt.Sprintf("%s(%s) %s", name, strings.Join(in, ", "), strings.Join(out, ", "))
+}
+
+func (m *generator) method(recv string, fn *ast.FuncDecl) string {
+
+	var (
+		file    = m.files[fn]
+		params  = m.params(file, fn.Type.Params)
+		results = m.params(file, fn.Type.Results)
+		ret     = len(results)
+		failing = ret > 0 && results[ret-1].typ == "error"
+		fixed   = len(params)
+		args    = make([]string, 0)
+	)
+
+	if failing {
+		ret--
+	}
+
+	m.printf("%q: func(%sc *lua.Call) int {\n", fn.Name.Name, recv)
+
+	if recv != "" {
+		m.printf("m := self.(*%s)\n", m.selfType)
+	}
+
+	if len(params) == 1 && params[0].typ == "*lua.Call" && len(results) == 1 && results[0].typ == "int" {
+		m.printf("return m.%s(c)\n},\n", fn.Name.Name)
+		return fn.Name.Name + "(...)"
+	}
+
+	// a leading *lua.Call is given the current call
+	if len(params) > 0 && params[0].typ == "*lua.Call" {
+		params = params[1:]
+		fixed--
+		args = append(args, "c")
+	}
+
+	if fixed > 0 && params[fixed-1].variadic {
+		fixed--
+	}
+
+	var (
+		required = fixed
+	)
+
+	for required > 0 && params[required-1].pointer {
+		required--
+	}
+
+	var (
+		described = signature(fn.Name.Name, params, fixed, required, results[:ret])
+	)
+
+	// extra arguments are dropped as lua functions do
+	m.printf("c.CheckArgs(%d, -1)\n", required)
+
+	for index := 0; index < fixed; index++ {
+
+		var (
+			name = fmt.Sprintf("a%d", index)
+		)
+
+		m.printf("var %s %s\n", name, params[index].typ)
+		m.arg(name, strconv.Itoa(index+1), params[index])
+
+		args = append(args, name)
+	}
+
+	if fixed < len(params) {
+
+		var (
+			p = params[fixed]
+		)
+
+		m.printf("var va []%s\n", p.typ)
+		m.printf("for n := %d; n <= c.NArgs(); n++ {\n", fixed+1)
+		m.printf("var v %s\n", p.typ)
+		m.arg("v", "n", p)
+		m.printf("va = append(va, v)\n}\n")
+
+		args = append(args, "va...")
+	}
+
+	var (
+		outs = make([]string, 0)
+	)
+
+	for index := 0; index < ret; index++ {
+		outs = append(outs, fmt.Sprintf("r%d", index))
+	}
+
+	if failing {
+		outs = append(outs, "err")
+	}
+
+	var (
+		call = fmt.Sprintf("m.%s(%s)", fn.Name.Name, strings.Join(args, ", "))
+	)
+
+	if len(outs) == 0 {
+		m.printf("%s\nreturn 0\n},\n", call)
+		return described
+	}
+
+	m.printf("%s := %s\n", strings.Join(outs, ", "), call)
+
+	if failing {
+		m.printf("if err != nil {\nreturn c.Fail(%d, err)\n}\n", ret)
+	}
+
+	for index := 0; index < ret; index++ {
+		if push, ok := builtins[results[index].typ]; ok {
+			m.printf("c.Push(%s(r%d))\n", push, index)
+		} else {
+			m.printf("c.Return(r%d)\n", index)
+		}
+	}
+
+	m.printf("return %d\n},\n", ret)
+
+	return described
+}
+
+func (m *generator) generate(t *target) {
+
+	var (
+		signatures = make([]string, 0)
+	)
+
+	m.selfType = t.name
+
+	sort.Slice(t.methods, func(i, j int) bool {
+		return t.methods[i].Name.Name < t.methods[j].Name.Name
+	})
+
+	switch t.kind {
+	case kindMembers:
+		m.printf("func (m *%s) LuaFunctions() map[string]lua.StaticFunction {\n", t.name)
+		m.printf("return map[string]lua.StaticFunction{\n")
+
+		for _, fn := range t.methods {
+			signatures = append(signatures, m.method("", fn))
+		}
+	case kindTyped:
+		m.printf("func (*%s) LuaMethods() map[string]lua.StaticMethod {\n", t.name)
+		m.printf("return map[string]lua.StaticMethod{\n")
+
+		for _, fn := range t.methods {
+			signatures = append(signatures, m.method("self interface{}, ", fn))
+		}
+	}
+
+	m.printf("}\n}\n\n")
+
+	m.printf("func (*%s) LuaSignatures() map[string]string {\n", t.name)
+	m.printf("return map[string]string{\n")
+
+	for index, fn := range t.methods {
+		m.printf("%q: %q,\n", fn.Name.Name, signatures[index])
+	}
+
+	m.printf("}\n}\n\n")
+}
+
+func main() {
+
+	log.SetFlags(0)
+	log.SetPrefix("luagen: ")
+	flag.Parse()
+
+	if *typeNames == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	var (
+		dir   = "."
+		fset  = token.NewFileSet()
+		names = strings.Split(*typeNames, ",")
+	)
+
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if len(pkgs) != 1 {
+		log.Fatalf("%d packages found in %s", len(pkgs), dir)
+	}
+
+	var (
+		g = &generator{
+			imports: make(map[string]string),
+			files:   make(map[*ast.FuncDecl]*ast.File),
+		}
+		targets = make(map[string]*target)
+	)
+
+	for name, pkg := range pkgs {
+
+		g.pkg = name
+
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+
+				switch x := decl.(type) {
+				case *ast.GenDecl:
+					for _, spec := range x.Specs {
+
+						ts, ok := spec.(*ast.TypeSpec)
+
+						if !ok {
+							continue
+						}
+
+						st, ok := ts.Type.(*ast.StructType)
+
+						if !ok {
+							continue
+						}
+
+						switch {
+						case embeds(file, st, "ModuleMembers"):
+							targets[ts.Name.Name] = &target{name: ts.Name.Name, kind: kindMembers}
+						case embeds(file, st, "Typed"):
+							targets[ts.Name.Name] = &target{name: ts.Name.Name, kind: kindTyped}
+						}
+					}
+				}
+			}
+		}
+
+		for _, file := range pkg.Files {
+			for _, decl := range file.Decls {
+
+				fn, ok := decl.(*ast.FuncDecl)
+
+				if !ok || !fn.Name.IsExported() {
+					continue
+				}
+
+				switch fn.Name.Name {
+				case "LuaFunctions", "LuaMethods", "LuaSignatures":
+					continue
+				}
+
+				if t, ok := targets[receiver(fn)]; ok {
+					if t.kind == kindMembers && fn.Name.Name == "Close" {
+						continue
+					}
+
+					t.methods = append(t.methods, fn)
+					g.files[fn] = file
+				}
+			}
+		}
+	}
+
+	var (
+		body = &generator{
+			pkg:     g.pkg,
+			imports: g.imports,
+			files:   g.files,
+		}
+	)
+
+	for _, name := range names {
+
+		t, ok := targets[name]
+
+		if !ok {
+			log.Fatalf("type %s not found or does not embed lua.ModuleMembers or lua.Typed", name)
+		}
+
+		body.generate(t)
+	}
+
+	g.printf("// Code generated by luagen. DO NOT EDIT.\n\n")
+	g.printf("package %s\n\n", g.pkg)
+	g.printf("import (\n")
+	g.printf("%q\n", luaPath)
+
+	for path, name := range g.imports {
+		if filepath.Base(path) == name {
+			g.printf("%q\n", path)
+		} else {
+			g.printf("%s %q\n", name, path)
+		}
+	}
+
+	g.printf(")\n\n")
+	g.buf.Write(body.buf.Bytes())
+
+	src, err := format.Source(g.buf.Bytes())
+
+	if err != nil {
+		log.Fatalf("format generated code: %s\n%s", err, g.buf.Bytes())
+	}
+
+	var (
+		name = *output
+	)
+
+	if name == "" {
+		name = strings.ToLower(strings.Replace(*typeNames, ",", "_", -1)) + "_lua.go"
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, name), src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
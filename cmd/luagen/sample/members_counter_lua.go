@@ -0,0 +1,209 @@
+// Code generated by luagen. DO NOT EDIT.
+
+package sample
+
+import (
+	"github.com/mz-eco/lua"
+)
+
+func (m *Members) LuaFunctions() map[string]lua.StaticFunction {
+	return map[string]lua.StaticFunction{
+		"Add": func(c *lua.Call) int {
+			c.CheckArgs(2, -1)
+			var a0 int
+			if x, ok := c.Arg(1).(lua.Number); ok {
+				a0 = int(int64(x))
+			} else if c.Arg(1) != lua.Nil {
+				c.Decode(1, &a0)
+			}
+			var a1 int
+			if x, ok := c.Arg(2).(lua.Number); ok {
+				a1 = int(int64(x))
+			} else if c.Arg(2) != lua.Nil {
+				c.Decode(2, &a1)
+			}
+			r0 := m.Add(a0, a1)
+			c.Push(lua.Number(r0))
+			return 1
+		},
+		"Count": func(c *lua.Call) int {
+			return m.Count(c)
+		},
+		"Counter": func(c *lua.Call) int {
+			c.CheckArgs(1, -1)
+			var a0 uint
+			if x, ok := c.Arg(1).(lua.Number); ok {
+				a0 = uint(uint64(x))
+			} else if c.Arg(1) != lua.Nil {
+				c.Decode(1, &a0)
+			}
+			r0 := m.Counter(a0)
+			c.Return(r0)
+			return 1
+		},
+		"Divide": func(c *lua.Call) int {
+			c.CheckArgs(2, -1)
+			var a0 int64
+			if x, ok := c.Arg(1).(lua.Number); ok {
+				a0 = int64(x)
+			} else if c.Arg(1) != lua.Nil {
+				c.Decode(1, &a0)
+			}
+			var a1 int64
+			if x, ok := c.Arg(2).(lua.Number); ok {
+				a1 = int64(x)
+			} else if c.Arg(2) != lua.Nil {
+				c.Decode(2, &a1)
+			}
+			r0, err := m.Divide(a0, a1)
+			if err != nil {
+				return c.Fail(1, err)
+			}
+			c.Push(lua.Number(r0))
+			return 1
+		},
+		"Greet": func(c *lua.Call) int {
+			c.CheckArgs(1, -1)
+			var a0 string
+			if x, ok := c.Arg(1).(lua.String); ok {
+				a0 = string(x)
+			} else if c.Arg(1) != lua.Nil {
+				c.Decode(1, &a0)
+			}
+			var a1 *string
+			if c.Arg(2) != lua.Nil {
+				c.Decode(2, &a1)
+			}
+			r0 := m.Greet(a0, a1)
+			c.Push(lua.String(r0))
+			return 1
+		},
+		"Join": func(c *lua.Call) int {
+			c.CheckArgs(1, -1)
+			var a0 string
+			if x, ok := c.Arg(1).(lua.String); ok {
+				a0 = string(x)
+			} else if c.Arg(1) != lua.Nil {
+				c.Decode(1, &a0)
+			}
+			var va []string
+			for n := 2; n <= c.NArgs(); n++ {
+				var v string
+				if x, ok := c.Arg(n).(lua.String); ok {
+					v = string(x)
+				} else if c.Arg(n) != lua.Nil {
+					c.Decode(n, &v)
+				}
+				va = append(va, v)
+			}
+			r0 := m.Join(a0, va...)
+			c.Push(lua.String(r0))
+			return 1
+		},
+		"Named": func(c *lua.Call) int {
+			c.CheckArgs(1, -1)
+			var a0 string
+			if x, ok := c.Arg(1).(lua.String); ok {
+				a0 = string(x)
+			} else if c.Arg(1) != lua.Nil {
+				c.Decode(1, &a0)
+			}
+			r0 := m.Named(c, a0)
+			c.Push(lua.String(r0))
+			return 1
+		},
+		"Norm": func(c *lua.Call) int {
+			c.CheckArgs(1, -1)
+			var a0 Point
+			if c.Arg(1) != lua.Nil {
+				c.Decode(1, &a0)
+			}
+			r0 := m.Norm(a0)
+			c.Push(lua.Number(r0))
+			return 1
+		},
+		"Not": func(c *lua.Call) int {
+			c.CheckArgs(1, -1)
+			var a0 bool
+			if x, ok := c.Arg(1).(lua.Bool); ok {
+				a0 = bool(x)
+			} else if c.Arg(1) != lua.Nil {
+				c.Decode(1, &a0)
+			}
+			r0 := m.Not(a0)
+			c.Push(lua.Bool(r0))
+			return 1
+		},
+		"Origin": func(c *lua.Call) int {
+			c.CheckArgs(0, -1)
+			r0 := m.Origin()
+			c.Return(r0)
+			return 1
+		},
+		"Scale": func(c *lua.Call) int {
+			c.CheckArgs(2, -1)
+			var a0 float64
+			if x, ok := c.Arg(1).(lua.Number); ok {
+				a0 = float64(x)
+			} else if c.Arg(1) != lua.Nil {
+				c.Decode(1, &a0)
+			}
+			var a1 float32
+			if x, ok := c.Arg(2).(lua.Number); ok {
+				a1 = float32(x)
+			} else if c.Arg(2) != lua.Nil {
+				c.Decode(2, &a1)
+			}
+			r0 := m.Scale(a0, a1)
+			c.Push(lua.Number(r0))
+			return 1
+		},
+	}
+}
+
+func (*Members) LuaSignatures() map[string]string {
+	return map[string]string{
+		"Add":     "Add(int, int) int",
+		"Count":   "Count(...)",
+		"Counter": "Counter(uint) *sample.Counter",
+		"Divide":  "Divide(int64, int64) int64",
+		"Greet":   "Greet(string, [*string]) string",
+		"Join":    "Join(string, ...string) string",
+		"Named":   "Named(string) string",
+		"Norm":    "Norm(sample.Point) int",
+		"Not":     "Not(bool) bool",
+		"Origin":  "Origin() sample.Point",
+		"Scale":   "Scale(float64, float32) float64",
+	}
+}
+
+func (*Counter) LuaMethods() map[string]lua.StaticMethod {
+	return map[string]lua.StaticMethod{
+		"Inc": func(self interface{}, c *lua.Call) int {
+			m := self.(*Counter)
+			c.CheckArgs(1, -1)
+			var a0 int
+			if x, ok := c.Arg(1).(lua.Number); ok {
+				a0 = int(int64(x))
+			} else if c.Arg(1) != lua.Nil {
+				c.Decode(1, &a0)
+			}
+			r0 := m.Inc(a0)
+			c.Push(lua.Number(r0))
+			return 1
+		},
+		"Reset": func(self interface{}, c *lua.Call) int {
+			m := self.(*Counter)
+			c.CheckArgs(0, -1)
+			m.Reset()
+			return 0
+		},
+	}
+}
+
+func (*Counter) LuaSignatures() map[string]string {
+	return map[string]string{
+		"Inc":   "Inc(int) int",
+		"Reset": "Reset()",
+	}
+}
@@ -0,0 +1,111 @@
+// Package sample is bound both by reflection and by luagen, its tests check
+// that the two paths behave the same.
+package sample
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/mz-eco/lua"
+)
+
+//go:generate go run github.com/mz-eco/lua/cmd/luagen -type Members,Counter
+
+type Members struct {
+	lua.ModuleMembers
+}
+
+type Point struct {
+	X int
+	Y int
+}
+
+type Counter struct {
+	lua.Typed
+	N int
+}
+
+func (*Members) Add(a int, b int) int {
+	return a + b
+}
+
+func (*Members) Scale(x float64, by float32) float64 {
+	return x * float64(by)
+}
+
+func (*Members) Join(sep string, parts ...string) string {
+	return strings.Join(parts, sep)
+}
+
+func (*Members) Greet(name string, greeting *string) string {
+
+	if greeting == nil {
+		return "hello " + name
+	}
+
+	return *greeting + " " + name
+}
+
+func (*Members) Divide(a int64, b int64) (int64, error) {
+
+	if b == 0 {
+		return 0, errors.New("divide by zero")
+	}
+
+	return a / b, nil
+}
+
+func (*Members) Not(b bool) bool {
+	return !b
+}
+
+func (*Members) Norm(p Point) int {
+	return p.X*p.X + p.Y*p.Y
+}
+
+func (*Members) Origin() Point {
+	return Point{}
+}
+
+func (*Members) Counter(n uint) *Counter {
+	return &Counter{N: int(n)}
+}
+
+func (*Members) Count(c *lua.Call) int {
+	return c.Push(lua.Number(c.NArgs()))
+}
+
+func (*Members) Named(c *lua.Call, suffix string) string {
+	return c.Name() + suffix
+}
+
+func (m *Counter) Inc(by int) int {
+	m.N += by
+	return m.N
+}
+
+func (m *Counter) Reset() {
+	m.N = 0
+}
+
+func Loader(reflect bool) lua.ModuleLoader {
+	return func() *lua.Module {
+
+		var (
+			m = &lua.Module{
+				Name:    "sample",
+				Members: &Members{},
+				Reflect: reflect,
+			}
+		)
+
+		m.Define(&lua.Type{
+			UUID:    "4d8f0f6c-5b7e-4d0a-9a53-0c3f1e6b2a71",
+			Name:    "Counter",
+			Type:    lua.GoType((*Counter)(nil)),
+			Reflect: reflect,
+		})
+
+		return m
+	}
+}
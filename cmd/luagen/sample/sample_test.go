@@ -0,0 +1,130 @@
+package sample
+
+import (
+	"testing"
+
+	"github.com/mz-eco/lua"
+	glua "github.com/yuin/gopher-lua"
+)
+
+var (
+	script = `
+		local s = require("sample")
+		local out = {}
+
+		local function check(name, fn, ...)
+			local ok, a, b = pcall(fn, ...)
+			out[#out + 1] = string.format("%s %s %s %s", name, tostring(ok), tostring(a), tostring(b))
+		end
+
+		check("add", s.Add, 1, 2)
+		check("add string", s.Add, "3", 4)
+		check("add nil", s.Add, nil, 4)
+		check("add table", s.Add, {}, 4)
+		check("add arity", s.Add, 1)
+		check("add extra", s.Add, 1, 2, 3)
+		check("scale", s.Scale, 1.5, 2)
+		check("join", s.Join, ",", "a", "b", 3)
+		check("join empty", s.Join, ",")
+		check("greet", s.Greet, "bob")
+		check("greet hi", s.Greet, "bob", "hi")
+		check("divide", s.Divide, 7, 2)
+		check("divide zero", s.Divide, 1, 0)
+		check("not", s.Not, true)
+		check("not number", s.Not, 0)
+		check("norm", s.Norm, {X = 3, Y = 4})
+		check("norm missing", s.Norm, {X = 3})
+		check("origin", function() return s.Origin().X end)
+		check("count", s.Count, 1, 2, 3)
+		check("named", s.Named, "!")
+
+		local c = s.Counter(2)
+		check("inc", function() return c:Inc(3) end)
+		check("inc bad", function() return c:Inc("x") end)
+		check("reset", function() c:Reset(); return c.N end)
+
+		local names = {}
+		for name in pairs(s) do names[#names + 1] = name end
+		table.sort(names)
+		for _, name in ipairs(names) do out[#out + 1] = "help " .. tostring(help(s[name])) end
+		out[#out + 1] = "help " .. tostring(help(c.Inc)) .. " " .. tostring(help(c.Reset))
+
+		return table.concat(out, "\n")
+	`
+)
+
+func run(t testing.TB, reflect bool) string {
+
+	var (
+		vm = lua.New()
+	)
+
+	defer lua.Close(vm)
+
+	lua.LoadModule(vm, Loader(reflect))
+	lua.LoadIntrospection(vm)
+
+	if err := vm.DoString(script); err != nil {
+		t.Fatal(err)
+	}
+
+	return vm.Get(-1).String()
+}
+
+func TestStaticMatchesReflect(t *testing.T) {
+
+	var (
+		reflective = run(t, true)
+		static     = run(t, false)
+	)
+
+	if reflective != static {
+		t.Fatalf("reflect:\n%s\n\nstatic:\n%s", reflective, static)
+	}
+}
+
+func benchmark(b *testing.B, reflect bool) {
+
+	var (
+		vm = lua.New()
+	)
+
+	defer lua.Close(vm)
+
+	lua.LoadModule(vm, Loader(reflect))
+
+	err := vm.DoString(`
+		local s = require("sample")
+		function bench(n)
+			local c = s.Counter(0)
+			for i = 1, n do
+				s.Add(i, 1)
+				s.Join(",", "a", "b")
+				c:Inc(1)
+			end
+		end
+	`)
+
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ResetTimer()
+
+	err = vm.CallByParam(glua.P{
+		Fn:      vm.GetGlobal("bench"),
+		Protect: true,
+	}, lua.Number(b.N))
+
+	if err != nil {
+		b.Fatal(err)
+	}
+}
+
+func BenchmarkReflect(b *testing.B) {
+	benchmark(b, true)
+}
+
+func BenchmarkStatic(b *testing.B) {
+	benchmark(b, false)
+}
@@ -0,0 +1,281 @@
+package lua
+
+import (
+	"context"
+	R "reflect"
+	"sync"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// Future is a value produced by go asynchronously, a coroutine waiting on it
+// through Call.Await is resumed by the Scheduler once it is settled.
+type Future struct {
+	done   chan struct{}
+	once   sync.Once
+	values []interface{}
+	err    error
+}
+
+func NewFuture() *Future {
+	return &Future{
+		done: make(chan struct{}),
+	}
+}
+
+func Async(fn func() ([]interface{}, error)) *Future {
+
+	var (
+		f = NewFuture()
+	)
+
+	go func() {
+		values, err := fn()
+
+		if err != nil {
+			f.Reject(err)
+		} else {
+			f.Resolve(values...)
+		}
+	}()
+
+	return f
+}
+
+// Receive settles with the next value of ch and whether ch is still open.
+func Receive(ch interface{}) *Future {
+
+	var (
+		v = R.ValueOf(ch)
+	)
+
+	if v.Kind() != R.Chan || v.Type().ChanDir()&R.RecvDir == 0 {
+		panic("receive future must be given a readable channel")
+	}
+
+	return Async(func() ([]interface{}, error) {
+		x, ok := v.Recv()
+
+		if !ok {
+			return []interface{}{nil, false}, nil
+		}
+
+		return []interface{}{x.Interface(), true}, nil
+	})
+}
+
+// Resolve settles m with values, a future settles once, later calls to
+// Resolve or Reject are ignored.
+func (m *Future) Resolve(values ...interface{}) {
+	m.once.Do(func() {
+		m.values = values
+		close(m.done)
+	})
+}
+
+func (m *Future) Reject(err error) {
+	m.once.Do(func() {
+		m.err = err
+		close(m.done)
+	})
+}
+
+func (m *Future) Done() <-chan struct{} {
+	return m.done
+}
+
+func (m *Future) Result() ([]interface{}, error) {
+	<-m.done
+	return m.values, m.err
+}
+
+func (m *Call) Yield(values ...Value) int {
+	return m.vm.Yield(values...)
+}
+
+// Await suspends the calling coroutine until f is settled, the go function
+// must return its result: return c.Await(f). The coroutine is resumed with
+// the values of f, or nil and the error message if f was rejected.
+func (m *Call) Await(f *Future) int {
+
+	var (
+		ud = m.vm.NewUserData()
+	)
+
+	ud.Value = f
+
+	return m.vm.Yield(ud)
+}
+
+type task struct {
+	thread *VM
+	cancel context.CancelFunc
+	fn     *Function
+	args   []Value
+	future *Future
+}
+
+type Scheduler struct {
+	vm      *VM
+	ready   []*task
+	waiting map[*task]struct{}
+	wake    chan *task
+	done    chan struct{}
+	err     error
+}
+
+func NewScheduler(vm *VM) *Scheduler {
+	return &Scheduler{
+		vm:      vm,
+		ready:   make([]*task, 0),
+		waiting: make(map[*task]struct{}),
+		wake:    make(chan *task),
+		done:    make(chan struct{}),
+	}
+}
+
+func (m *Scheduler) Spawn(fn *Function, args ...Value) {
+
+	thread, cancel := m.vm.NewThread()
+
+	m.ready = append(m.ready, &task{
+		thread: thread,
+		cancel: cancel,
+		fn:     fn,
+		args:   args,
+	})
+}
+
+func (m *task) close() {
+
+	if m.cancel != nil {
+		m.cancel()
+	}
+}
+
+func (m *Scheduler) settle(t *task) {
+
+	var (
+		f       = t.future
+		encoder = NewEncoder(m.vm, FlagSkipMethod)
+	)
+
+	t.future = nil
+	t.args = make([]Value, 0)
+
+	if f.err != nil {
+		t.args = append(t.args, Nil, String(f.err.Error()))
+		return
+	}
+
+	for _, x := range f.values {
+		value, err := encoder.Encode(x)
+
+		if err != nil {
+			t.args = []Value{Nil, String(err.Error())}
+			return
+		}
+
+		t.args = append(t.args, value)
+	}
+}
+
+func (m *Scheduler) step(t *task) {
+
+	state, err, values := m.vm.Resume(t.thread, t.fn, t.args...)
+
+	t.args = nil
+
+	switch {
+	case err != nil:
+		if m.err == nil {
+			m.err = err
+		}
+		t.close()
+	case state == lua.ResumeYield:
+		if len(values) == 1 {
+			if ud, ok := values[0].(*lua.LUserData); ok {
+				if f, ok := ud.Value.(*Future); ok {
+					t.future = f
+					m.waiting[t] = struct{}{}
+
+					go func(done chan struct{}) {
+						select {
+						case <-f.done:
+						case <-done:
+							return
+						}
+
+						select {
+						case m.wake <- t:
+						case <-done:
+						}
+					}(m.done)
+
+					return
+				}
+			}
+		}
+
+		m.ready = append(m.ready, t)
+	default:
+		t.close()
+	}
+}
+
+// cancel stops the pending coroutines and the goroutines waiting for their
+// futures.
+func (m *Scheduler) cancel() {
+
+	close(m.done)
+	m.done = make(chan struct{})
+
+	for _, t := range m.ready {
+		t.close()
+	}
+
+	for t := range m.waiting {
+		t.close()
+	}
+
+	m.ready = make([]*task, 0)
+	m.waiting = make(map[*task]struct{})
+}
+
+// Run resumes the spawned coroutines until all of them finished, the first
+// error raised by a coroutine is returned once the others are done. If ctx is
+// done first the pending coroutines are cancelled and ctx.Err() is returned.
+func (m *Scheduler) Run(ctx context.Context) error {
+
+	for len(m.ready) > 0 || len(m.waiting) > 0 {
+
+		if err := ctx.Err(); err != nil {
+			m.cancel()
+			m.err = nil
+			return err
+		}
+
+		if len(m.ready) == 0 {
+			select {
+			case t := <-m.wake:
+				delete(m.waiting, t)
+				m.settle(t)
+				m.ready = append(m.ready, t)
+			case <-ctx.Done():
+				continue
+			}
+		}
+
+		var (
+			t = m.ready[0]
+		)
+
+		m.ready = m.ready[1:]
+		m.step(t)
+	}
+
+	err := m.err
+	m.err = nil
+
+	return err
+}
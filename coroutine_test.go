@@ -0,0 +1,173 @@
+package lua
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func awaitFunction(vm *VM, futures chan *Future) *Function {
+
+	return vm.NewFunction(VMGFunction(&Invoker{
+		Name: "wait",
+		GoFunc: func(c *Call) int {
+			f := NewFuture()
+			futures <- f
+			return c.Await(f)
+		},
+	}))
+}
+
+func TestSchedulerAwait(t *testing.T) {
+
+	var (
+		vm      = New()
+		futures = make(chan *Future, 2)
+	)
+
+	defer Close(vm)
+
+	vm.SetGlobal("wait", awaitFunction(vm, futures))
+
+	err := vm.DoString(`
+		results = {}
+		function worker(name)
+			local value, err = wait()
+			coroutine.yield()
+			results[name] = value or err
+		end
+	`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		s      = NewScheduler(vm)
+		worker = vm.GetGlobal("worker").(*Function)
+	)
+
+	s.Spawn(worker, String("a"))
+	s.Spawn(worker, String("b"))
+
+	go func() {
+		(<-futures).Resolve("resolved")
+		(<-futures).Reject(errors.New("rejected"))
+	}()
+
+	if err := s.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	results := vm.GetGlobal("results").(*Table)
+
+	if x := results.RawGetString("a").String(); x != "resolved" {
+		t.Fatalf("a got %q", x)
+	}
+
+	if x := results.RawGetString("b").String(); x != "rejected" {
+		t.Fatalf("b got %q", x)
+	}
+}
+
+func TestSchedulerCancel(t *testing.T) {
+
+	var (
+		vm      = New()
+		futures = make(chan *Future, 10)
+		before  = runtime.NumGoroutine()
+	)
+
+	defer Close(vm)
+
+	vm.SetGlobal("wait", awaitFunction(vm, futures))
+
+	if err := vm.DoString(`function worker() wait() end`); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		s           = NewScheduler(vm)
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+
+	for index := 0; index < 10; index++ {
+		s.Spawn(vm.GetGlobal("worker").(*Function))
+	}
+
+	go func() {
+		for index := 0; index < 10; index++ {
+			<-futures
+		}
+		cancel()
+	}()
+
+	if err := s.Run(ctx); err != context.Canceled {
+		t.Fatalf("run returned %v", err)
+	}
+
+	for index := 0; index < 100 && runtime.NumGoroutine() > before; index++ {
+		time.Sleep(time.Millisecond)
+	}
+
+	if n := runtime.NumGoroutine(); n > before {
+		t.Fatalf("%d goroutines left after cancel", n-before)
+	}
+
+	if err := s.Run(context.Background()); err != nil {
+		t.Fatalf("run after cancel returned %v", err)
+	}
+}
+
+func TestFutureSettleOnce(t *testing.T) {
+
+	var (
+		f = NewFuture()
+	)
+
+	f.Resolve(1)
+	f.Reject(errors.New("late"))
+	f.Resolve(2)
+
+	values, err := f.Result()
+
+	if err != nil || len(values) != 1 || values[0] != 1 {
+		t.Fatalf("future settled with %v %v", values, err)
+	}
+}
+
+type threadMembers struct {
+	ModuleMembers
+	vm *VM
+}
+
+func (m *threadMembers) Current(c *Call, n int) bool {
+	return c.VM() != m.vm && n == 1
+}
+
+func TestMemberCallThread(t *testing.T) {
+
+	var (
+		vm = New()
+	)
+
+	defer Close(vm)
+
+	LoadModule(vm, func() *Module {
+		return &Module{Name: "thread", Members: &threadMembers{vm: vm}}
+	})
+
+	err := vm.DoString(`
+		local thread = require("thread")
+		local co = coroutine.create(function() return thread.Current(1) end)
+		local ok, current = coroutine.resume(co)
+		assert(ok and current, "member should see the coroutine thread")
+		assert(not thread.Current(1), "member should see the main thread")
+	`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+}
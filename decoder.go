@@ -2,6 +2,7 @@ package lua
 
 import (
 	"errors"
+	"fmt"
 	R "reflect"
 
 	lua "github.com/yuin/gopher-lua"
@@ -10,7 +11,6 @@ import (
 var (
 	errNotPointer = errors.New("argument must a pointer")
 	errNotType    = errors.New("not support <reflect.Type> to <lua.Value>")
-	errChanType   = errors.New("channel tye is not lua.LChannel")
 )
 
 type errorFieldNotFound struct {
@@ -27,7 +27,7 @@ type errorClassType struct {
 }
 
 func (m *errorClassType) Error() string {
-	return ""
+	return fmt.Sprintf("user data of type <%v> is not <%s>", m.src, m.to)
 }
 
 type errorConvert struct {
@@ -36,14 +36,17 @@ type errorConvert struct {
 }
 
 func (m *errorConvert) Error() string {
-	return ""
+	return fmt.Sprintf("could not convert lua type %s to <%s>", m.src, m.to)
 }
 
 type Decoder struct {
 	encoding
-	vm         *VM
-	skipMethod bool
-	base       Value
+	DecodeOptions
+	vm          *VM
+	skipMethod  bool
+	zeroMissing bool
+	base        Value
+	tracker     *tracker
 }
 
 func (m *Decoder) errConvert(src Value, to R.Type) error {
@@ -74,9 +77,11 @@ func (m *Decoder) fieldNotFound(field string) error {
 func NewDeocder(vm *VM, flags EncodingFlags) *Decoder {
 
 	return &Decoder{
-		vm:         vm,
-		skipMethod: (flags & FlagSkipMethod) == FlagSkipMethod,
-		base:       Nil,
+		DecodeOptions: DefaultDecodeOptions,
+		vm:            vm,
+		skipMethod:    (flags & FlagSkipMethod) == FlagSkipMethod,
+		zeroMissing:   (flags & FlagZeroMissing) == FlagZeroMissing,
+		base:          Nil,
 	}
 }
 
@@ -90,15 +95,39 @@ func (m *Decoder) Decode(src Value, to interface{}) (err error) {
 	case R.Type:
 		return m.error(errNotType)
 	default:
-		v = R.ValueOf(src)
+		v = R.ValueOf(to)
 	}
 
 	if v.Kind() != R.Ptr {
 		return m.error(errNotPointer)
 	}
 
-	return m.decode(src, v)
+	m.tracker = newTracker(m.DecodeOptions)
 
+	defer func() {
+		m.tracker = nil
+	}()
+
+	return m.decode(src, v.Elem())
+
+}
+
+func (m *Decoder) track() *tracker {
+
+	if m.tracker == nil {
+		m.tracker = newTracker(m.DecodeOptions)
+	}
+
+	return m.tracker
+}
+
+func (m *Decoder) visit(src Value) error {
+
+	if !m.track().visit(src) {
+		return m.errorCycle()
+	}
+
+	return nil
 }
 
 func (m *Decoder) class(src Value, to R.Value) error {
@@ -122,7 +151,12 @@ func (m *Decoder) class(src Value, to R.Value) error {
 	}
 
 	ud := src.(*lua.LUserData)
-	uv := R.ValueOf(ud)
+	uv := R.ValueOf(ud.Value)
+
+	if !uv.IsValid() {
+		return m.errClassType(nil, t)
+	}
+
 	ut := uv.Type()
 
 	if ut != t {
@@ -140,34 +174,59 @@ func (m *Decoder) mapping(src Value, to R.Value) error {
 		return m.errConvert(src, to.Type())
 	}
 
+	if to.Kind() == R.Ptr {
+		if to.IsNil() {
+			to.Set(R.New(to.Type().Elem()))
+		}
+
+		to = to.Elem()
+	}
+
+	if to.CanAddr() {
+		m.track().remember(src, to.Addr())
+	}
+
+	if err := m.visit(src); err != nil {
+		return err
+	}
+
+	defer m.tracker.release(src)
+
 	var (
-		ot  = to.Type()
-		tbl = src.(*Table)
+		plan = planOf(to.Type())
+		tbl  = src.(*Table)
 	)
 
-	for index := 0; index < ot.NumField(); index++ {
+	for _, fp := range plan.fields {
 
 		var (
-			fv  = to.Field(index)
-			ft  = ot.Field(index)
-			tag = makeTags(ft)
+			fv = to.Field(fp.index)
 		)
 
-		if tag.skip {
+		if fp.tag.skip || !fp.exported {
+			continue
+		}
+
+		if fp.class {
 			continue
 		}
 
-		lv := m.vm.GetField(tbl, ft.Name)
+		lv := m.vm.GetField(tbl, fp.name)
 
 		if lv == Nil {
-			if tag.option {
+			if fp.tag.option {
+				continue
+			}
+
+			if m.zeroMissing {
+				fv.Set(R.Zero(fp.t))
 				continue
 			}
 
-			return m.fieldNotFound(ft.Name)
+			return m.fieldNotFound(fp.name)
 
 		}
-		err := m.decode(lv, fv, ft.Name)
+		err := m.decode(lv, fv, fp.name)
 
 		if err != nil {
 			return err
@@ -198,41 +257,44 @@ func (m *Decoder) checkNil(src Value, to R.Value) bool {
 func (m *Decoder) slice(src Value, to R.Value) error {
 
 	var (
-		t      = R.TypeOf(to)
-		values = R.MakeSlice(t, 0, 0)
+		t = to.Type()
 	)
 
 	if src.Type() != lua.LTTable {
 		return m.errConvert(src, t)
 	}
 
+	if err := m.visit(src); err != nil {
+		return err
+	}
+
+	defer m.tracker.release(src)
+
 	var (
-		tbl   = src.(*Table)
-		err   error
-		et    = t.Elem()
-		index = 0
+		tbl    = src.(*Table)
+		n, key = sequence(tbl)
+		values = R.MakeSlice(t, n, n)
 	)
 
-	tbl.ForEach(func(_ lua.LValue, value lua.LValue) {
-
-		if err != nil {
-			return
-		}
+	if key != Nil && m.Sequence == SequenceReject {
+		return m.errorSequence(key)
+	}
 
-		e := R.New(et)
+	for index := 0; index < n; index++ {
 
-		err = m.decode(value, e.Elem(), index)
+		var (
+			value = tbl.RawGetInt(index + 1)
+		)
 
-		if err != nil {
-			return
+		if value == Nil {
+			continue
 		}
 
-		values = R.Append(values, e.Elem())
-
-	})
+		err := m.decode(value, values.Index(index), index)
 
-	if err != nil {
-		return err
+		if err != nil {
+			return err
+		}
 	}
 
 	to.Set(values)
@@ -250,8 +312,14 @@ func (m *Decoder) dir(src Value, to R.Value) error {
 		return m.errConvert(src, to.Type())
 	}
 
+	if err := m.visit(src); err != nil {
+		return err
+	}
+
+	defer m.tracker.release(src)
+
 	var (
-		mt   = R.TypeOf(to)
+		mt   = to.Type()
 		kt   = mt.Key()
 		vt   = mt.Elem()
 		dirs = R.MakeMap(mt)
@@ -266,7 +334,7 @@ func (m *Decoder) dir(src Value, to R.Value) error {
 
 		kv := R.New(kt)
 
-		err = m.decode(key, kv)
+		err = m.decode(key, kv.Elem())
 
 		if err != nil {
 			return
@@ -274,13 +342,13 @@ func (m *Decoder) dir(src Value, to R.Value) error {
 
 		vv := R.New(vt)
 
-		err = m.decode(value, vv)
+		err = m.decode(value, vv.Elem(), key)
 
 		if err != nil {
 			return
 		}
 
-		dirs.SetMapIndex(kv, vv)
+		dirs.SetMapIndex(kv.Elem(), vv.Elem())
 
 	})
 
@@ -299,16 +367,18 @@ func (m *Decoder) ptr(src Value, to R.Value) error {
 	}
 
 	var (
-		ptr = R.New(to.Type())
+		ptr = R.New(to.Type().Elem())
 	)
 
+	m.track().remember(src, ptr)
+
 	err := m.decode(src, ptr.Elem())
 
 	if err != nil {
 		return err
 	}
 
-	to.Set(ptr.Elem())
+	to.Set(ptr)
 
 	return nil
 }
@@ -316,42 +386,21 @@ func (m *Decoder) ptr(src Value, to R.Value) error {
 func (m *Decoder) builtin(src Value, to R.Value) error {
 
 	var (
-		i = to.Interface().(Value)
-
-		check = func(lvt lua.LValueType) error {
-
-			if src.Type() != lvt {
-				return m.errorBuiltin(src, to.Type())
-			}
-
-			to.Set(R.ValueOf(src))
-
-			return nil
-		}
+		t = to.Type()
 	)
 
-	switch i.(type) {
-	case *Function:
-		return check(lua.LTFunction)
-	case Value:
-		to.Set(R.ValueOf(src))
+	if t == typeValue {
+		to.Set(R.ValueOf(&src).Elem())
 		return nil
-	case Number:
-		return check(lua.LTNumber)
-	case *Table:
-		return check(lua.LTTable)
-	case Bool:
-		return check(lua.LTBool)
-	case String:
-		return check(lua.LTString)
-	case lua.LChannel:
-		return check(lua.LTTable)
-	case *lua.LUserData:
-		return check(lua.LTUserData)
-	default:
-		return m.errorBuiltin(src, to.Type())
 	}
 
+	if !R.TypeOf(src).AssignableTo(t) {
+		return m.errorBuiltin(src, t)
+	}
+
+	to.Set(R.ValueOf(src))
+
+	return nil
 }
 
 func (m *Decoder) fn(from Value, to R.Value) error {
@@ -365,14 +414,27 @@ func (m *Decoder) channel(src Value, to R.Value) error {
 	}
 
 	var (
-		t = R.TypeOf(to)
+		t = to.Type()
 	)
 
-	if t != typeChannel {
-		return m.error(errChanType)
+	ch, ok := src.(lua.LChannel)
+
+	if !ok {
+		return m.errConvert(src, t)
 	}
 
-	to.Set(R.ValueOf(src))
+	if typeChannel.ConvertibleTo(t) {
+		to.Set(R.ValueOf(ch).Convert(t))
+		return nil
+	}
+
+	x, err := goChannel(m.vm, ch, t)
+
+	if err != nil {
+		return m.error(err)
+	}
+
+	to.Set(x)
 	return nil
 }
 
@@ -420,7 +482,7 @@ func (m *Decoder) decode(src Value, to R.Value, trace ...interface{}) error {
 	if len(trace) > 0 {
 
 		var (
-			size = len(trace)
+			size = len(m.traces)
 		)
 
 		if m.traces == nil {
@@ -439,19 +501,39 @@ func (m *Decoder) decode(src Value, to R.Value, trace ...interface{}) error {
 	}
 
 	var (
-		t = to.Type()
+		t       = to.Type()
+		tracker = m.track()
+	)
+
+	defer tracker.leave()
+
+	if !tracker.enter() {
+		return m.errorDepth(tracker.options.MaxDepth)
+	}
+
+	if v, ok := tracker.reuse(src, t); ok {
+		to.Set(v)
+		return nil
+	}
+
+	var (
+		plan = typePlanOf(t)
 	)
 
 	switch {
-	case t.Implements(typeClass):
+	case plan.class:
 		return m.class(src, to)
-	case t.Implements(typeTableMapping):
+	case plan.mapping:
+		return m.mapping(src, to)
+	case plan.mappingAddr:
 		return m.mapping(src, to)
-	case t.Implements(typeValue):
+	case plan.value:
 		return m.builtin(src, to)
 	}
 
 	switch to.Kind() {
+	case R.Bool:
+		return m.bool(src, to)
 	case R.Ptr:
 		return m.ptr(src, to)
 	case R.Chan:
@@ -469,7 +551,7 @@ func (m *Decoder) decode(src Value, to R.Value, trace ...interface{}) error {
 	case R.Slice:
 		return m.slice(src, to)
 	case R.Map:
-		return m.mapping(src, to)
+		return m.dir(src, to)
 	case R.Func:
 		return NotSupportFunc
 	case R.Interface, R.Complex64, R.Complex128, R.Array, R.UnsafePointer, R.Invalid, R.Uintptr:
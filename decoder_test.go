@@ -0,0 +1,224 @@
+package lua
+
+import (
+	"testing"
+)
+
+type decodeNode struct {
+	TableMapping
+	Name string
+	Next *decodeNode
+}
+
+func TestDecoderCycles(t *testing.T) {
+
+	var (
+		vm = New()
+	)
+
+	defer Close(vm)
+
+	err := vm.DoString(`
+		cycle = { Name = "cycle" }
+		cycle.Next = cycle
+		deep = { Name = "1", Next = { Name = "2", Next = { Name = "3", Next = { Name = "4", Next = { Name = "5" } } } } }
+		list = {}
+		list[1] = list
+	`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		global  string
+		options DecodeOptions
+		check   func(x *decodeNode) bool
+		fail    interface{}
+	}{
+		{"error", "cycle", DecodeOptions{Cycle: CycleError}, nil, &errorCycle{}},
+		{"reuse", "cycle", DecodeOptions{Cycle: CycleReuse}, func(x *decodeNode) bool {
+			return x.Next == x && x.Name == "cycle"
+		}, nil},
+		{"deep", "deep", DecodeOptions{MaxDepth: 16}, func(x *decodeNode) bool {
+			return x.Next.Next.Next.Next != nil
+		}, nil},
+		{"too deep", "deep", DecodeOptions{MaxDepth: 3}, nil, &errorDepth{}},
+	}
+
+	for _, test := range tests {
+
+		var (
+			decoder = NewDeocder(vm, FlagSkipMethod|FlagZeroMissing)
+			x       *decodeNode
+		)
+
+		decoder.DecodeOptions = test.options
+
+		err := decoder.Decode(vm.GetGlobal(test.global), &x)
+
+		switch test.fail.(type) {
+		case *errorCycle:
+			if _, ok := what(err).(*errorCycle); !ok {
+				t.Errorf("%s: want a cycle error, got %v", test.name, err)
+			}
+		case *errorDepth:
+			if _, ok := what(err).(*errorDepth); !ok {
+				t.Errorf("%s: want a depth error, got %v", test.name, err)
+			}
+		default:
+			if err != nil {
+				t.Errorf("%s: %s", test.name, err)
+			} else if !test.check(x) {
+				t.Errorf("%s: decoded %+v", test.name, x)
+			}
+		}
+	}
+
+	var (
+		list [][]interface{}
+	)
+
+	if _, ok := what(NewDeocder(vm, 0).Decode(vm.GetGlobal("list"), &list)).(*errorCycle); !ok {
+		t.Error("a slice holding itself should fail")
+	}
+}
+
+func TestDecoderSequence(t *testing.T) {
+
+	var (
+		vm = New()
+	)
+
+	defer Close(vm)
+
+	tests := []struct {
+		script string
+		policy SequencePolicy
+		want   []int
+		fail   bool
+	}{
+		{`return {1, 2, 3}`, SequenceIgnore, []int{1, 2, 3}, false},
+		{`return {1, 2, 3, x = 4}`, SequenceIgnore, []int{1, 2, 3}, false},
+		{`return {1, 2, 3, x = 4}`, SequenceReject, nil, true},
+		{`return {[1] = 1, [3] = 3, [2] = 2}`, SequenceReject, []int{1, 2, 3}, false},
+		{`return {1, nil, 3}`, SequenceReject, []int{1, 0, 3}, false},
+		{`return {}`, SequenceReject, []int{}, false},
+	}
+
+	for _, test := range tests {
+
+		if err := vm.DoString(test.script); err != nil {
+			t.Fatal(err)
+		}
+
+		var (
+			src     = vm.Get(-1)
+			decoder = NewDeocder(vm, 0)
+			x       []int
+		)
+
+		vm.Pop(1)
+
+		decoder.Sequence = test.policy
+
+		err := decoder.Decode(src, &x)
+
+		if test.fail {
+			if _, ok := what(err).(*errorSequence); !ok {
+				t.Errorf("%s: want a sequence error, got %v", test.script, err)
+			}
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("%s: %s", test.script, err)
+			continue
+		}
+
+		if len(x) != len(test.want) {
+			t.Errorf("%s: decoded %v", test.script, x)
+			continue
+		}
+
+		for index := range x {
+			if x[index] != test.want[index] {
+				t.Errorf("%s: decoded %v", test.script, x)
+				break
+			}
+		}
+	}
+}
+
+func TestDecoderBuiltins(t *testing.T) {
+
+	var (
+		vm = New()
+	)
+
+	defer Close(vm)
+
+	Define(vm, identityType)
+	Define(vm, resourceType)
+
+	obj, err := NewEncoder(vm, 0).Encode(&identityObject{Name: "x"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		tbl   = vm.NewTable()
+		empty = vm.NewUserData()
+	)
+
+	tests := []struct {
+		name string
+		src  Value
+		to   interface{}
+		fail interface{}
+	}{
+		{"value", Number(1), new(Value), nil},
+		{"table", tbl, new(*Table), nil},
+		{"number", Number(1), new(Number), nil},
+		{"string", String("s"), new(String), nil},
+		{"table from number", Number(1), new(*Table), &errorBuiltin{}},
+		{"function from table", tbl, new(*Function), &errorBuiltin{}},
+		{"typed", obj, new(*identityObject), nil},
+		{"typed of another type", obj, new(*resourceObject), &errorClassType{}},
+		{"typed without value", empty, new(*identityObject), &errorClassType{}},
+	}
+
+	for _, test := range tests {
+
+		err := NewDeocder(vm, 0).Decode(test.src, test.to)
+
+		switch test.fail.(type) {
+		case *errorBuiltin:
+			if _, ok := what(err).(*errorBuiltin); !ok {
+				t.Errorf("%s: want a builtin error, got %v", test.name, err)
+			}
+		case *errorClassType:
+			if e, ok := what(err).(*errorClassType); !ok || e.Error() == "" {
+				t.Errorf("%s: want a class error, got %v", test.name, err)
+			}
+		default:
+			if err != nil {
+				t.Errorf("%s: %s", test.name, err)
+			}
+		}
+	}
+
+	var (
+		x interface{} = 1
+	)
+
+	if err := As(vm, Nil, &x); err != nil || x != nil {
+		t.Fatalf("nil decoded as %v %v", x, err)
+	}
+
+	if (&errorConvert{}).Error() == "" {
+		t.Fatal("convert errors should describe the conversion")
+	}
+}
@@ -0,0 +1,392 @@
+// Package doc generates EmmyLua (LuaLS) annotation stubs and Markdown
+// references from the modules exposed to lua.
+package doc
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	R "reflect"
+	"strings"
+
+	"github.com/mz-eco/lua"
+)
+
+type Generator struct {
+	modules []lua.ModuleInfo
+	names   map[R.Type]string
+}
+
+func NewGenerator(modules ...lua.ModuleInfo) *Generator {
+
+	var (
+		g = &Generator{
+			modules: modules,
+			names:   make(map[R.Type]string),
+		}
+	)
+
+	for _, m := range modules {
+		g.collect(m)
+	}
+
+	return g
+}
+
+func (m *Generator) collect(module lua.ModuleInfo) {
+
+	for _, x := range module.Types {
+
+		m.names[x.Type] = x.Name
+
+		if x.Type.Kind() == R.Ptr {
+			m.names[x.Type.Elem()] = x.Name
+		}
+	}
+
+	for _, child := range module.Modules {
+		m.collect(child)
+	}
+}
+
+// LuaType names t as a LuaLS type, types defined by a module are named after
+// their lua name.
+func (m *Generator) LuaType(t R.Type) string {
+
+	if t == nil {
+		return "any"
+	}
+
+	if name, ok := m.names[t]; ok {
+		return name
+	}
+
+	switch t.Kind() {
+	case R.Bool:
+		return "boolean"
+	case R.Int, R.Int8, R.Int16, R.Int32, R.Int64,
+		R.Uint, R.Uint8, R.Uint16, R.Uint32, R.Uint64:
+		return "integer"
+	case R.Float32, R.Float64:
+		return "number"
+	case R.String:
+		return "string"
+	case R.Slice, R.Array:
+		return m.LuaType(t.Elem()) + "[]"
+	case R.Map:
+		return fmt.Sprintf("table<%s, %s>", m.LuaType(t.Key()), m.LuaType(t.Elem()))
+	case R.Ptr:
+		return m.LuaType(t.Elem())
+	case R.Struct:
+		return "table"
+	case R.Func:
+		return m.function(t)
+	default:
+		return "any"
+	}
+}
+
+func (m *Generator) function(t R.Type) string {
+
+	var (
+		params  = make([]string, 0)
+		results = make([]string, 0)
+	)
+
+	for index := 0; index < t.NumIn(); index++ {
+
+		if t.IsVariadic() && index == t.NumIn()-1 {
+			params = append(params, "...: "+m.LuaType(t.In(index).Elem()))
+			continue
+		}
+
+		params = append(params, fmt.Sprintf("arg%d: %s", index+1, m.LuaType(t.In(index))))
+	}
+
+	for index := 0; index < t.NumOut(); index++ {
+
+		if t.Out(index) == R.TypeOf((*error)(nil)).Elem() {
+			continue
+		}
+
+		results = append(results, m.LuaType(t.Out(index)))
+	}
+
+	if len(results) == 0 {
+		return fmt.Sprintf("fun(%s)", strings.Join(params, ", "))
+	}
+
+	return fmt.Sprintf("fun(%s): %s", strings.Join(params, ", "), strings.Join(results, ", "))
+}
+
+// Signature describes fn with lua types, as the markdown reference shows it.
+func (m *Generator) Signature(fn lua.FunctionInfo) string {
+
+	var (
+		params  = make([]string, 0)
+		results = make([]string, 0)
+	)
+
+	for index, param := range fn.Params {
+
+		switch {
+		case param.Variadic:
+			params = append(params, "...: "+m.LuaType(param.Type.Elem()))
+		case param.Optional:
+			params = append(params, fmt.Sprintf("arg%d?: %s", index+1, m.LuaType(param.Type)))
+		default:
+			params = append(params, fmt.Sprintf("arg%d: %s", index+1, m.LuaType(param.Type)))
+		}
+	}
+
+	for _, result := range fn.Results {
+		results = append(results, m.LuaType(result))
+	}
+
+	if len(results) == 0 {
+		return fmt.Sprintf("%s(%s)", fn.Name, strings.Join(params, ", "))
+	}
+
+	return fmt.Sprintf("%s(%s): %s", fn.Name, strings.Join(params, ", "), strings.Join(results, ", "))
+}
+
+func comment(w io.Writer, doc string) {
+
+	if doc == "" {
+		return
+	}
+
+	for _, line := range strings.Split(strings.TrimSpace(doc), "\n") {
+		fmt.Fprintf(w, "---%s\n", line)
+	}
+}
+
+func (m *Generator) signature(w io.Writer, owner string, sep string, fn lua.FunctionInfo) {
+
+	var (
+		names = make([]string, 0)
+	)
+
+	comment(w, fn.Doc)
+
+	for index, param := range fn.Params {
+
+		var (
+			name = fmt.Sprintf("arg%d", index+1)
+		)
+
+		if param.Variadic {
+			fmt.Fprintf(w, "---@param ... %s\n", m.LuaType(param.Type.Elem()))
+			names = append(names, "...")
+			continue
+		}
+
+		if param.Optional {
+			fmt.Fprintf(w, "---@param %s? %s\n", name, m.LuaType(param.Type))
+		} else {
+			fmt.Fprintf(w, "---@param %s %s\n", name, m.LuaType(param.Type))
+		}
+
+		names = append(names, name)
+	}
+
+	for _, result := range fn.Results {
+		fmt.Fprintf(w, "---@return %s\n", m.LuaType(result))
+	}
+
+	fmt.Fprintf(w, "function %s%s%s(%s) end\n\n", owner, sep, fn.Name, strings.Join(names, ", "))
+}
+
+func (m *Generator) annotations(w io.Writer, name string, module lua.ModuleInfo) {
+
+	comment(w, module.Doc)
+	fmt.Fprintf(w, "---@class %s\n", name)
+
+	for _, value := range module.Values {
+		comment(w, value.Doc)
+		fmt.Fprintf(w, "---@field %s %s\n", value.Name, m.LuaType(value.Type))
+	}
+
+	for _, child := range module.Modules {
+		fmt.Fprintf(w, "---@field %s %s.%s\n", child.Name, name, child.Name)
+	}
+
+	if strings.Contains(name, ".") {
+		// the parent table is declared first
+		fmt.Fprintf(w, "%s = {}\n\n", name)
+	} else {
+		fmt.Fprintf(w, "local %s = {}\n\n", name)
+	}
+
+	for _, fn := range module.Functions {
+		m.signature(w, name, ".", fn)
+	}
+
+	for _, x := range module.Types {
+
+		comment(w, x.Doc)
+		fmt.Fprintf(w, "---@class %s\n", x.Name)
+
+		for _, field := range x.Fields {
+			comment(w, field.Doc)
+			fmt.Fprintf(w, "---@field %s %s\n", field.Name, m.LuaType(field.Type))
+		}
+
+		fmt.Fprintf(w, "local %s = {}\n\n", x.Name)
+
+		for _, method := range x.Methods {
+			m.signature(w, x.Name, ":", method)
+		}
+	}
+
+	for _, child := range module.Modules {
+		m.annotations(w, name+"."+child.Name, child)
+	}
+}
+
+// Annotations writes the LuaLS definition file of module, meant to be put in
+// the library path of the language server.
+func (m *Generator) Annotations(w io.Writer, module lua.ModuleInfo) error {
+
+	var (
+		b = bufio.NewWriter(w)
+	)
+
+	fmt.Fprintf(b, "---@meta %s\n\n", module.Name)
+
+	// a dotted name needs the tables holding it, a local can not be dotted
+	parts := strings.Split(module.Name, ".")
+
+	for index := 0; index < len(parts)-1; index++ {
+		if index == 0 {
+			fmt.Fprintf(b, "local %s = {}\n", parts[0])
+		} else {
+			fmt.Fprintf(b, "%s = {}\n", strings.Join(parts[:index+1], "."))
+		}
+	}
+
+	if len(parts) > 1 {
+		fmt.Fprintln(b)
+	}
+
+	m.annotations(b, module.Name, module)
+
+	fmt.Fprintf(b, "return %s\n", module.Name)
+
+	return b.Flush()
+}
+
+func (m *Generator) markdown(w io.Writer, level int, name string, module lua.ModuleInfo) {
+
+	var (
+		heading = strings.Repeat("#", level)
+	)
+
+	fmt.Fprintf(w, "%s %s\n\n", heading, name)
+
+	if module.Doc != "" {
+		fmt.Fprintf(w, "%s\n\n", strings.TrimSpace(module.Doc))
+	}
+
+	if len(module.Values) > 0 {
+		fmt.Fprintf(w, "| Field | Type | Description |\n|---|---|---|\n")
+
+		for _, value := range module.Values {
+			fmt.Fprintf(w, "| `%s.%s` | `%s` | %s |\n", name, value.Name, m.LuaType(value.Type), inline(value.Doc))
+		}
+
+		fmt.Fprintln(w)
+	}
+
+	for _, fn := range module.Functions {
+		m.entry(w, level+1, name+"."+m.Signature(fn), fn.Doc)
+	}
+
+	for _, x := range module.Types {
+
+		fmt.Fprintf(w, "%s# type %s\n\n", heading, x.Name)
+
+		if x.Doc != "" {
+			fmt.Fprintf(w, "%s\n\n", strings.TrimSpace(x.Doc))
+		}
+
+		if len(x.Fields) > 0 {
+			fmt.Fprintf(w, "| Field | Type | Description |\n|---|---|---|\n")
+
+			for _, field := range x.Fields {
+				fmt.Fprintf(w, "| `%s` | `%s` | %s |\n", field.Name, m.LuaType(field.Type), inline(field.Doc))
+			}
+
+			fmt.Fprintln(w)
+		}
+
+		for _, method := range x.Methods {
+			m.entry(w, level+2, x.Name+":"+m.Signature(method), method.Doc)
+		}
+	}
+
+	for _, child := range module.Modules {
+		m.markdown(w, level+1, name+"."+child.Name, child)
+	}
+}
+
+func (m *Generator) entry(w io.Writer, level int, signature string, doc string) {
+
+	fmt.Fprintf(w, "%s `%s`\n\n", strings.Repeat("#", level), signature)
+
+	if doc != "" {
+		fmt.Fprintf(w, "%s\n\n", strings.TrimSpace(doc))
+	}
+}
+
+func inline(doc string) string {
+	return strings.Join(strings.Fields(doc), " ")
+}
+
+// Markdown writes the reference documentation of module.
+func (m *Generator) Markdown(w io.Writer, module lua.ModuleInfo) error {
+
+	var (
+		b = bufio.NewWriter(w)
+	)
+
+	m.markdown(b, 1, module.Name, module)
+
+	return b.Flush()
+}
+
+// WriteDir writes <name>.lua and <name>.md for every module of the generator.
+func (m *Generator) WriteDir(dir string) error {
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	for _, module := range m.modules {
+
+		for ext, write := range map[string]func(io.Writer, lua.ModuleInfo) error{
+			".lua": m.Annotations,
+			".md":  m.Markdown,
+		} {
+			f, err := os.Create(filepath.Join(dir, module.Name+ext))
+
+			if err != nil {
+				return err
+			}
+
+			err = write(f, module)
+
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,126 @@
+package doc
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mz-eco/lua"
+)
+
+var (
+	update = flag.Bool("update", false, "rewrite the golden files")
+)
+
+type members struct {
+	lua.ModuleMembers
+}
+
+func (m *members) Add(a int, b *int) int {
+	return a
+}
+
+func (m *members) Join(sep string, parts ...string) (string, error) {
+	return sep, nil
+}
+
+type counter struct {
+	lua.Typed
+	N    int
+	Tags []string
+}
+
+func (m *counter) Inc(by int) int {
+	return m.N + by
+}
+
+func module() lua.ModuleInfo {
+
+	var (
+		m = &lua.Module{
+			Name:    "app.tools",
+			Doc:     "Tools for scripts.",
+			Docs:    map[string]string{"Add": "Add adds b to a.", "VERSION": "The version."},
+			Members: &members{},
+		}
+		child = &lua.Module{
+			Name:    "time",
+			Members: &members{},
+		}
+	)
+
+	m.Set("VERSION", "1.0")
+
+	m.Define(&lua.Type{
+		UUID: "3c1e7a9b-4d2f-4b8e-a6c5-1f0d9e8b7a62",
+		Name: "Counter",
+		Doc:  "Counter counts.",
+		Docs: map[string]string{"N": "The count."},
+		Type: lua.GoType((*counter)(nil)),
+	})
+
+	child.Set("ZONE", "UTC")
+	m.Submodule(child)
+
+	return m.Info()
+}
+
+func golden(t *testing.T, name string, got []byte) {
+
+	var (
+		path = filepath.Join("testdata", name)
+	)
+
+	if *update {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Fatalf("%s differs, got:\n%s", name, got)
+	}
+}
+
+func TestAnnotations(t *testing.T) {
+
+	var (
+		b = &bytes.Buffer{}
+		m = module()
+	)
+
+	if err := NewGenerator(m).Annotations(b, m); err != nil {
+		t.Fatal(err)
+	}
+
+	golden(t, "tools.lua", b.Bytes())
+
+	vm := lua.New()
+	defer lua.Close(vm)
+
+	if err := vm.DoString(b.String()); err != nil {
+		t.Fatalf("annotations are not valid lua: %s", err)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+
+	var (
+		b = &bytes.Buffer{}
+		m = module()
+	)
+
+	if err := NewGenerator(m).Markdown(b, m); err != nil {
+		t.Fatal(err)
+	}
+
+	golden(t, "tools.md", b.Bytes())
+}
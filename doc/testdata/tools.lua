@@ -0,0 +1,48 @@
+---@meta app.tools
+
+local app = {}
+
+---Tools for scripts.
+---@class app.tools
+---The version.
+---@field VERSION string
+---@field time app.tools.time
+app.tools = {}
+
+---Add adds b to a.
+---@param arg1 integer
+---@param arg2? integer
+---@return integer
+function app.tools.Add(arg1, arg2) end
+
+---@param arg1 string
+---@param ... string
+---@return string
+function app.tools.Join(arg1, ...) end
+
+---Counter counts.
+---@class Counter
+---The count.
+---@field N integer
+---@field Tags string[]
+local Counter = {}
+
+---@param arg1 integer
+---@return integer
+function Counter:Inc(arg1) end
+
+---@class app.tools.time
+---@field ZONE string
+app.tools.time = {}
+
+---@param arg1 integer
+---@param arg2? integer
+---@return integer
+function app.tools.time.Add(arg1, arg2) end
+
+---@param arg1 string
+---@param ... string
+---@return string
+function app.tools.time.Join(arg1, ...) end
+
+return app.tools
@@ -28,18 +28,13 @@ func (m *EncodeChecker) class(src R.Type) error {
 
 func (m *EncodeChecker) mapping(src R.Type) error {
 
-	for index := 0; index < src.NumField(); index++ {
+	for _, fp := range planOf(src).fields {
 
-		var (
-			ft  = src.Field(index)
-			tag = makeTags(ft)
-		)
-
-		if tag.skip {
+		if fp.tag.skip {
 			continue
 		}
 
-		err := m.encode(ft.Type, ft.Name)
+		err := m.encode(fp.t, fp.name)
 
 		if err != nil {
 			if err != NotSupportFunc {
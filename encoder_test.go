@@ -0,0 +1,171 @@
+package lua
+
+import (
+	"testing"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+type encodeNode struct {
+	TableMapping
+	Name string
+	Next *encodeNode
+	Left *encodeNode
+}
+
+// what returns the error an EncodeError reports.
+func what(err error) error {
+
+	for {
+		e, ok := err.(*EncodeError)
+
+		if !ok {
+			return err
+		}
+
+		err = e.What
+	}
+}
+
+func TestEncoderReferences(t *testing.T) {
+
+	var (
+		vm     = New()
+		shared = &encodeNode{Name: "shared"}
+		cycle  = &encodeNode{Name: "cycle"}
+	)
+
+	defer Close(vm)
+
+	cycle.Next = cycle
+
+	tests := []struct {
+		name   string
+		src    interface{}
+		script string
+	}{
+		{"shared", &encodeNode{Next: shared, Left: shared}, `assert(rawequal(v.Next, v.Left))`},
+		{"cycle", cycle, `assert(rawequal(v.Next, v) and v.Next.Next.Name == "cycle")`},
+		{"slices", [][]int{{1}, {1}}, `assert(not rawequal(v[1], v[2]))`},
+		{"map", map[string]*encodeNode{"a": shared, "b": shared}, `assert(rawequal(v.a, v.b))`},
+	}
+
+	for _, test := range tests {
+
+		value, err := NewEncoder(vm, FlagSkipMethod).Encode(test.src)
+
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+
+		vm.SetGlobal("v", value)
+
+		if err := vm.DoString(test.script); err != nil {
+			t.Errorf("%s: %s", test.name, err)
+		}
+	}
+}
+
+func TestEncoderMaxDepth(t *testing.T) {
+
+	var (
+		vm   = New()
+		root = &encodeNode{}
+	)
+
+	defer Close(vm)
+
+	for index, node := 0, root; index < 8; index++ {
+		node.Next = &encodeNode{}
+		node = node.Next
+	}
+
+	tests := []struct {
+		depth int
+		fail  bool
+	}{
+		{0, false},
+		{4, true},
+		{9, true},
+		{10, false},
+	}
+
+	for _, test := range tests {
+
+		var (
+			encoder = NewEncoder(vm, FlagSkipMethod)
+		)
+
+		encoder.MaxDepth = test.depth
+
+		_, err := encoder.Encode(root)
+
+		if !test.fail {
+			if err != nil {
+				t.Errorf("max depth %d: %s", test.depth, err)
+			}
+			continue
+		}
+
+		if _, ok := what(err).(*errorDepth); !ok {
+			t.Errorf("max depth %d: want a depth error, got %v", test.depth, err)
+		}
+	}
+}
+
+func TestEncoderSorted(t *testing.T) {
+
+	var (
+		vm = New()
+	)
+
+	defer Close(vm)
+
+	err := vm.DoString(`
+		function order(t)
+			local keys = {}
+			for k in pairs(t) do keys[#keys + 1] = tostring(k) end
+			return table.concat(keys, ",")
+		end
+	`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		src  interface{}
+		want string
+	}{
+		{map[string]int{"d": 4, "b": 2, "a": 1, "c": 3, "e": 5, "f": 6}, "a,b,c,d,e,f"},
+		{map[float64]bool{1.5: true, -1: true, 0.5: true, -2: true}, "-2,-1,0.5,1.5"},
+		{map[interface{}]int{"b": 1, 2: 1, "a": 1, 1: 1}, "1,2,a,b"},
+		{&encodeNode{Name: "x"}, "Name"},
+	}
+
+	for _, test := range tests {
+
+		for round := 0; round < 10; round++ {
+
+			value, err := NewEncoder(vm, FlagSorted|FlagSkipMethod).Encode(test.src)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			err = vm.CallByParam(lua.P{Fn: vm.GetGlobal("order"), NRet: 1, Protect: true}, value)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := vm.Get(-1).String()
+			vm.Pop(1)
+
+			if got != test.want {
+				t.Fatalf("%v encoded in order %s, want %s", test.src, got, test.want)
+			}
+		}
+	}
+}
@@ -1,31 +1,54 @@
 package lua
 
 import (
+	"fmt"
 	R "reflect"
+	"sort"
 
 	"github.com/yuin/gopher-lua"
 )
 
+const (
+	DefaultMaxDepth = 128
+)
+
 type Encoder struct {
 	encoding
+	MaxDepth   int
 	vm         *VM
 	skipMethod bool
 	typed      bool
+	live       bool
+	sorted     bool
+	depth      int
+	refs       map[reference]Value
+}
+
+type reference struct {
+	ptr  uintptr
+	size int
+	t    R.Type
 }
 
 type EncodingFlags int
 
 const (
-	FlagSkipMethod EncodingFlags = 0x1 << 1
-	FlagTyped                    = 0x1 << 2
+	FlagSkipMethod  EncodingFlags = 0x1 << 1
+	FlagTyped                     = 0x1 << 2
+	FlagLiveBinding               = 0x1 << 3
+	FlagZeroMissing               = 0x1 << 4
+	FlagSorted                    = 0x1 << 5
 )
 
 func NewEncoder(vm *VM, flags EncodingFlags) *Encoder {
 
 	return &Encoder{
+		MaxDepth:   DefaultMaxDepth,
 		vm:         vm,
 		skipMethod: (flags & FlagSkipMethod) == FlagSkipMethod,
 		typed:      (flags & FlagTyped) == FlagTyped,
+		live:       (flags & FlagLiveBinding) == FlagLiveBinding,
+		sorted:     (flags & FlagSorted) == FlagSorted,
 	}
 }
 
@@ -40,6 +63,13 @@ func (m *Encoder) Encode(src interface{}) (to Value, err error) {
 		v = R.ValueOf(src)
 	}
 
+	m.refs = make(map[reference]Value)
+	m.depth = 0
+
+	defer func() {
+		m.refs = nil
+	}()
+
 	to = Nil
 	err = m.encode(v, &to)
 
@@ -50,6 +80,64 @@ func (m *Encoder) Encode(src interface{}) (to Value, err error) {
 	return to, nil
 }
 
+func (m *Encoder) reference(src R.Value) (reference, bool) {
+
+	var (
+		ref = reference{
+			t: src.Type(),
+		}
+	)
+
+	// zero sized values may all share one address, they have no identity
+	switch src.Kind() {
+	case R.Ptr:
+		if ref.t.Elem().Size() == 0 {
+			return ref, false
+		}
+		ref.ptr = src.Pointer()
+	case R.Map:
+		ref.ptr = src.Pointer()
+	case R.Slice:
+		if src.Len() == 0 || ref.t.Elem().Size() == 0 {
+			return ref, false
+		}
+		ref.ptr = src.Pointer()
+		ref.size = src.Len()
+	default:
+		return ref, false
+	}
+
+	return ref, ref.ptr != 0
+}
+
+func (m *Encoder) lookup(src R.Value, to *Value) bool {
+
+	ref, ok := m.reference(src)
+
+	if !ok || m.refs == nil {
+		return false
+	}
+
+	value, ok := m.refs[ref]
+
+	if ok {
+		*to = value
+	}
+
+	return ok
+}
+
+func (m *Encoder) remember(src R.Value, value Value) {
+
+	ref, ok := m.reference(src)
+
+	if !ok || m.refs == nil {
+		return
+	}
+
+	m.refs[ref] = value
+}
+
 func (m *Encoder) class(src R.Value, to *Value) error {
 
 	m.typed = true
@@ -63,44 +151,62 @@ func (m *Encoder) class(src R.Value, to *Value) error {
 		return m.errorClass(vt)
 	}
 
-	i := src.Interface().(class)
+	var (
+		state       = loadState(m.vm)
+		ref, cached = m.reference(src)
+	)
 
-	value := i.getValue()
+	if cached {
+		if ud, found := state.identities.load(ref); found {
+			*to = ud
+			return nil
+		}
+	}
 
-	if value != nil {
-		*to = value
-	} else {
-		ud := m.vm.NewUserData()
-		ud.Value = i
+	ud := m.vm.NewUserData()
+	ud.Value = src.Interface()
 
-		m.vm.SetMetatable(ud, m.vm.GetTypeMetatable(xt.name))
-		i.setValue(ud)
-		*to = ud
+	m.vm.SetMetatable(ud, m.vm.GetTypeMetatable(xt.name))
+
+	if cached {
+		state.identities.store(ref, ud)
 	}
 
+	state.resources.track(ud, ref, cached)
+	*to = ud
+
 	return nil
 }
 
 func (m *Encoder) mapping(src R.Value, to *Value) error {
 
 	var (
-		fields  = make(map[string]Value)
 		members map[string]GFunction
 		ot      = src.Type()
 		ov      = src
 	)
 
 	if ov.Kind() == R.Ptr {
+		if m.checkNil(ov, to) {
+			return nil
+		}
+
+		if m.lookup(src, to) {
+			return nil
+		}
+
 		ov = ov.Elem()
 		ot = ov.Type()
 	}
 
-	for index := 0; index < ov.NumField(); index++ {
+	tbl := m.vm.NewTable()
+	m.remember(src, tbl)
+
+	for _, fp := range planOf(ot).fields {
 
 		var (
-			fv    = ov.Field(index)
-			ft    = ot.Field(index)
-			tag   = makeTags(ft)
+			fv    = ov.Field(fp.index)
+			tag   = fp.tag
 			value = Nil
 		)
 
@@ -108,11 +214,11 @@ func (m *Encoder) mapping(src R.Value, to *Value) error {
 			continue
 		}
 
-		if ft.Type == TableMappingClass {
+		if fp.class {
 			continue
 		}
 
-		err := m.encode(fv, &value, ft.Name)
+		err := m.encode(fv, &value, fp.name)
 
 		if err != nil {
 			if err != NotSupportFunc {
@@ -124,24 +230,28 @@ func (m *Encoder) mapping(src R.Value, to *Value) error {
 			continue
 		}
 
-		fields[ft.Name] = value
+		m.vm.SetField(tbl, fp.name, value)
 
 	}
 
 	if !m.skipMethod {
-		members = memberFunctions(ov, nil)
+		members = memberFunctions(src, func(v R.Value, index int, i *Invoker) {
+			i.Self = true
+			i.Caller = func(vm *VM) (R.Value, error) {
+				return v.Method(index), nil
+			}
+		})
 	}
 
-	tbl := m.vm.NewTable()
-	for k, v := range fields {
-		m.vm.SetField(tbl, k, v)
-	}
+	if len(members) > 0 {
+		mt := m.vm.NewTable()
 
-	if members != nil {
-		m.vm.SetField(tbl, "__index",
+		m.vm.SetField(mt, "__index",
 			m.vm.SetFuncs(
 				m.vm.NewTable(), members),
 		)
+
+		m.vm.SetMetatable(tbl, mt)
 	}
 
 	*to = tbl
@@ -167,10 +277,16 @@ func (m *Encoder) checkNil(src R.Value, to *Value) bool {
 
 func (m *Encoder) slice(src R.Value, to *Value) error {
 
+	if m.lookup(src, to) {
+		return nil
+	}
+
 	var (
-		values = make([]Value, 0)
+		tbl = m.vm.CreateTable(src.Len(), 0)
 	)
 
+	m.remember(src, tbl)
+
 	for index := 0; index < src.Len(); index++ {
 
 		var (
@@ -183,13 +299,7 @@ func (m *Encoder) slice(src R.Value, to *Value) error {
 			return err
 		}
 
-		values = append(values, v)
-	}
-
-	tbl := m.vm.NewTable()
-
-	for _, value := range values {
-		tbl.Append(value)
+		tbl.RawSetInt(index+1, v)
 	}
 
 	*to = tbl
@@ -199,12 +309,23 @@ func (m *Encoder) slice(src R.Value, to *Value) error {
 
 func (m *Encoder) dir(src R.Value, to *Value) error {
 
+	if m.checkNil(src, to) || m.lookup(src, to) {
+		return nil
+	}
+
 	var (
-		values = make(map[Value]Value, 0)
-		err    error
+		keys = src.MapKeys()
+		tbl  = m.vm.CreateTable(0, len(keys))
+		err  error
 	)
 
-	for _, key := range src.MapKeys() {
+	m.remember(src, tbl)
+
+	if m.sorted {
+		sortKeys(keys)
+	}
+
+	for _, key := range keys {
 
 		var (
 			k, v = Nil, Nil
@@ -222,12 +343,6 @@ func (m *Encoder) dir(src R.Value, to *Value) error {
 			return err
 		}
 
-		values[k] = v
-	}
-
-	tbl := m.vm.NewTable()
-
-	for k, v := range values {
 		tbl.RawSet(k, v)
 	}
 
@@ -235,13 +350,64 @@ func (m *Encoder) dir(src R.Value, to *Value) error {
 	return nil
 }
 
+func sortKeys(keys []R.Value) {
+
+	sort.SliceStable(keys, func(i, j int) bool {
+
+		var (
+			a, b = keys[i], keys[j]
+		)
+
+		for a.Kind() == R.Interface || a.Kind() == R.Ptr {
+			if a.IsNil() {
+				break
+			}
+			a = a.Elem()
+		}
+
+		for b.Kind() == R.Interface || b.Kind() == R.Ptr {
+			if b.IsNil() {
+				break
+			}
+			b = b.Elem()
+		}
+
+		if a.Kind() != b.Kind() {
+			return a.Kind() < b.Kind()
+		}
+
+		switch a.Kind() {
+		case R.Int, R.Int8, R.Int16, R.Int32, R.Int64:
+			return a.Int() < b.Int()
+		case R.Uint, R.Uint8, R.Uint16, R.Uint32, R.Uint64, R.Uintptr:
+			return a.Uint() < b.Uint()
+		case R.Float32, R.Float64:
+			return a.Float() < b.Float()
+		case R.String:
+			return a.String() < b.String()
+		case R.Bool:
+			return !a.Bool() && b.Bool()
+		default:
+			return fmt.Sprint(a) < fmt.Sprint(b)
+		}
+	})
+}
+
 func (m *Encoder) ptr(src R.Value, to *Value) error {
 
-	if m.checkNil(src, to) {
+	if m.checkNil(src, to) || m.lookup(src, to) {
 		return nil
 	}
 
-	return m.encode(src.Elem(), to)
+	err := m.encode(src.Elem(), to)
+
+	if err != nil {
+		return err
+	}
+
+	m.remember(src, *to)
+
+	return nil
 }
 
 func (m *Encoder) builtin(src R.Value, x *Value) error {
@@ -284,7 +450,18 @@ func (m *Encoder) channel(src R.Value, to *Value) error {
 		return nil
 	}
 
-	*to = src.Interface().(lua.LChannel)
+	if src.Type().ConvertibleTo(typeChannel) {
+		*to = src.Convert(typeChannel).Interface().(lua.LChannel)
+		return nil
+	}
+
+	ch, err := luaChannel(m.vm, src)
+
+	if err != nil {
+		return m.error(err)
+	}
+
+	*to = ch
 
 	return nil
 }
@@ -319,10 +496,20 @@ func (m *Encoder) encode(src R.Value, to *Value, trace ...interface{}) error {
 		m.typed = typed
 	}()
 
+	m.depth++
+
+	defer func() {
+		m.depth--
+	}()
+
+	if m.MaxDepth > 0 && m.depth > m.MaxDepth {
+		return m.errorDepth(m.MaxDepth)
+	}
+
 	if len(trace) > 0 {
 
 		var (
-			size = len(trace)
+			size = len(m.traces)
 		)
 
 		if m.traces == nil {
@@ -340,28 +527,39 @@ func (m *Encoder) encode(src R.Value, to *Value, trace ...interface{}) error {
 		return m.error(errInvalidValue)
 	}
 
-	if src.CanInterface() {
-		i := src.Interface()
+	// the handlers below expect the concrete value, not the interface
+	if src.Kind() == R.Interface && !src.IsNil() {
+		src = src.Elem()
+	}
 
-		if _, ok := i.(Value); ok {
+	if t := dynamicType(src); t != nil && src.CanInterface() {
+		plan := typePlanOf(t)
+
+		if plan.value {
 			return m.builtin(src, to)
 		}
 
-		if _, ok := i.(class); ok {
+		if plan.class {
 			return m.class(src, to)
 		}
 
-		if _, ok := i.(tableMapping); ok {
+		if plan.mapping {
 
 			if m.typed {
 				return m.error(errTypedChild)
 			}
 
+			if m.live && src.Kind() == R.Ptr {
+				return m.binding(src, to)
+			}
+
 			return m.mapping(src, to)
 		}
 	}
 
 	switch src.Kind() {
+	case R.Bool:
+		return m.bool(src, to)
 	case R.Ptr:
 		return m.ptr(src, to)
 	case R.Chan:
@@ -379,7 +577,7 @@ func (m *Encoder) encode(src R.Value, to *Value, trace ...interface{}) error {
 	case R.Slice:
 		return m.slice(src, to)
 	case R.Map:
-		return m.mapping(src, to)
+		return m.dir(src, to)
 	case R.Func:
 		return m.fn(src, to)
 	case R.Interface, R.Complex64, R.Complex128, R.Array, R.UnsafePointer, R.Invalid, R.Uintptr:
@@ -44,6 +44,50 @@ func (m *errorClass) Error() string {
 	return fmt.Sprintf("type <%s> is implementd lua.Typed, but not found in this vm", m.class)
 }
 
+type errorDepth struct {
+	depth int
+}
+
+func (m *errorDepth) Error() string {
+	return fmt.Sprintf("value nested deeper than %d levels", m.depth)
+}
+
+type errorCycle struct {
+	field string
+}
+
+func (m *errorCycle) Error() string {
+	if len(m.field) == 0 {
+		return "table references itself"
+	}
+
+	return fmt.Sprintf("table references itself at field:%s", m.field)
+}
+
+type errorSequence struct {
+	key lua.LValue
+}
+
+func (m *errorSequence) Error() string {
+	return fmt.Sprintf("table is not a sequence, found key %s", m.key)
+}
+
+type errorChannel struct {
+	t R.Type
+}
+
+func (m *errorChannel) Error() string {
+	return fmt.Sprintf("channel <%s> can not be bridged, elements must be bools, numbers or strings", m.t)
+}
+
+type errorChannelDir struct {
+	t R.Type
+}
+
+func (m *errorChannelDir) Error() string {
+	return fmt.Sprintf("channel <%s> can not be bridged both ways, give it a direction", m.t)
+}
+
 type errorBuiltin struct {
 	from lua.LValueType
 	to   R.Type
@@ -74,6 +118,24 @@ func (m *encoding) errorBuiltin(from Value, to R.Type) error {
 		})
 }
 
+func (m *encoding) errorDepth(depth int) error {
+	return m.error(
+		&errorDepth{
+			depth: depth,
+		})
+}
+
+func (m *encoding) errorCycle() error {
+	return m.error(&errorCycle{})
+}
+
+func (m *encoding) errorSequence(key lua.LValue) error {
+	return m.error(
+		&errorSequence{
+			key: key,
+		})
+}
+
 func (m *encoding) errorClass(x R.Type) error {
 	return m.error(
 		&errorClass{
@@ -91,7 +153,7 @@ func (m *encoding) error(err error) error {
 		x := make([]string, 0)
 
 		for _, trace := range m.traces {
-			x = append(x, fmt.Sprintf("%s", trace))
+			x = append(x, fmt.Sprintf("%v", trace))
 		}
 
 		traces = strings.Join(x, ".")
@@ -8,6 +8,12 @@ import (
 	"github.com/yuin/gopher-lua"
 )
 
+const (
+	// errorMetatable names the metatable of the error objects in the
+	// registry.
+	errorMetatable = "mz-eco/lua.error"
+)
+
 type errTypeConvert struct {
 	field string
 	src   lua.LValueType
@@ -42,3 +48,100 @@ func (m errValueConvert) Error() string {
 	return fmt.Sprintf(
 		"cloud not convert value %s to go field:%s, type %s", m.src, m.field, m.dst)
 }
+
+type errArgCount struct {
+	min      int
+	max      int
+	variadic bool
+	given    int
+}
+
+func (m errArgCount) Error() string {
+
+	switch {
+	case m.variadic:
+		return fmt.Sprintf(
+			"want at least %d arguments, given %d", m.min, m.given)
+	case m.min == m.max:
+		return fmt.Sprintf(
+			"want %d arguments, given %d", m.min, m.given)
+	default:
+		return fmt.Sprintf(
+			"want %d to %d arguments, given %d", m.min, m.max, m.given)
+	}
+}
+
+func errorCode(err error) interface{} {
+
+	var (
+		code = reflect.ValueOf(err).MethodByName("Code")
+	)
+
+	if !code.IsValid() || code.Type().NumIn() != 0 || code.Type().NumOut() != 1 {
+		return nil
+	}
+
+	return code.Call(nil)[0].Interface()
+}
+
+// ErrorValue wraps err into a userdata exposing code and message fields,
+// code is taken from a Code() method of err when it has one.
+func ErrorValue(vm *VM, err error) Value {
+
+	var (
+		mt = vm.NewTypeMetatable(errorMetatable)
+		ud = vm.NewUserData()
+	)
+
+	if mt.RawGetString("__index") == lua.LNil {
+
+		check := func(vm *VM) error {
+
+			var (
+				ud = vm.CheckUserData(1)
+			)
+
+			err, ok := ud.Value.(error)
+
+			if !ok {
+				vm.ArgError(1, "error object expected")
+			}
+
+			return err
+		}
+
+		vm.SetField(mt, "__index", vm.NewFunction(func(vm *VM) int {
+
+			var (
+				err = check(vm)
+			)
+
+			switch vm.CheckString(2) {
+			case "code":
+				value, e := NewEncoder(vm, FlagSkipMethod).Encode(errorCode(err))
+
+				if e != nil {
+					value = lua.LNil
+				}
+
+				vm.Push(value)
+			case "message":
+				vm.Push(lua.LString(err.Error()))
+			default:
+				vm.Push(lua.LNil)
+			}
+
+			return 1
+		}))
+
+		vm.SetField(mt, "__tostring", vm.NewFunction(func(vm *VM) int {
+			vm.Push(lua.LString(check(vm).Error()))
+			return 1
+		}))
+	}
+
+	ud.Value = err
+	vm.SetMetatable(ud, mt)
+
+	return ud
+}
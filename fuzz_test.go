@@ -0,0 +1,218 @@
+package lua
+
+import (
+	"strings"
+	"testing"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+var (
+	fuzzKeys = []string{"ID", "Title", "Leaf", "Leaves", "Tags", "Name", "Value", "Flag", "N"}
+)
+
+// fuzzValue builds a lua value out of data, tables may reference their
+// parent so cycles are covered too.
+func fuzzValue(vm *VM, data []byte, depth int, parent *Table) (Value, []byte) {
+
+	if len(data) == 0 {
+		return Nil, data
+	}
+
+	var (
+		op = data[0]
+	)
+
+	data = data[1:]
+
+	switch op % 8 {
+	case 0:
+		return Nil, data
+	case 1:
+		return Bool(op&0x80 != 0), data
+	case 2:
+		return Number(int8(op)), data
+	case 3:
+		return Number(float64(op) / 7), data
+	case 4:
+		var (
+			n = int(op>>3) % 8
+		)
+
+		if n > len(data) {
+			n = len(data)
+		}
+
+		return String(data[:n]), data[n:]
+	case 5, 6:
+		if depth > 6 {
+			return Nil, data
+		}
+
+		var (
+			tbl = vm.NewTable()
+			n   = int(op>>3) % 6
+		)
+
+		for index := 0; index < n && len(data) > 0; index++ {
+
+			var (
+				value Value
+			)
+
+			value, data = fuzzValue(vm, data, depth+1, tbl)
+
+			if op%8 == 5 {
+				tbl.RawSetInt(index+1, value)
+			} else {
+				tbl.RawSetString(fuzzKeys[(int(op)+index)%len(fuzzKeys)], value)
+			}
+		}
+
+		return tbl, data
+	default:
+		if parent != nil {
+			return parent, data
+		}
+
+		return vm.NewFunction(func(vm *VM) int { return 0 }), data
+	}
+}
+
+// noPanic runs fn, lua errors raised by a conversion are expected, any other
+// panic fails the test.
+func noPanic(t *testing.T, fn func()) {
+
+	defer func() {
+
+		r := recover()
+
+		if r == nil {
+			return
+		}
+
+		if _, ok := r.(*lua.ApiError); ok {
+			return
+		}
+
+		t.Fatalf("panic: %v", r)
+	}()
+
+	fn()
+}
+
+func fuzzTargets() []func() interface{} {
+	return []func() interface{}{
+		func() interface{} { return new(benchNode) },
+		func() interface{} { return new(*benchLeaf) },
+		func() interface{} { return new([]int) },
+		func() interface{} { return new([]string) },
+		func() interface{} { return new(map[string]interface{}) },
+		func() interface{} { return new(map[int]bool) },
+		func() interface{} { return new(interface{}) },
+		func() interface{} { return new(float32) },
+		func() interface{} { return new(uint8) },
+		func() interface{} { return new(*Table) },
+	}
+}
+
+func fuzzSeeds(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{2})
+	f.Add([]byte{4 | 3<<3, 'a', 'b', 'c'})
+	f.Add([]byte{5 | 3<<3, 2, 3, 4})
+	f.Add([]byte{6 | 5<<3, 2, 4 | 1<<3, 'x', 5 | 2<<3, 7, 2, 1, 6 | 1<<3, 7})
+}
+
+func FuzzAs(f *testing.F) {
+
+	fuzzSeeds(f)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+
+		var (
+			vm = New()
+		)
+
+		defer Close(vm)
+
+		value, _ := fuzzValue(vm, data, 0, nil)
+
+		for _, target := range fuzzTargets() {
+			noPanic(t, func() {
+				As(vm, value, target())
+			})
+		}
+	})
+}
+
+func FuzzDecode(f *testing.F) {
+
+	fuzzSeeds(f)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+
+		var (
+			vm = New()
+		)
+
+		defer Close(vm)
+
+		value, _ := fuzzValue(vm, data, 0, nil)
+
+		for _, target := range fuzzTargets() {
+			for _, cycle := range []CyclePolicy{CycleError, CycleReuse} {
+				noPanic(t, func() {
+
+					var (
+						decoder = NewDeocder(vm, FlagSkipMethod)
+					)
+
+					decoder.Cycle = cycle
+					decoder.Decode(value, target())
+				})
+			}
+		}
+	})
+}
+
+func FuzzTypedSetter(f *testing.F) {
+
+	fuzzSeeds(f)
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+
+		var (
+			vm = New()
+		)
+
+		defer Close(vm)
+
+		Define(vm, benchTypedType)
+
+		obj, err := NewEncoder(vm, FlagTyped).Encode(&benchTyped{})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		value, _ := fuzzValue(vm, data, 0, nil)
+
+		set := vm.NewFunction(func(vm *VM) int {
+			vm.SetField(vm.Get(1), vm.CheckString(2), vm.Get(3))
+			return 0
+		})
+
+		for _, name := range append(fuzzKeys, "Add", "") {
+
+			err := vm.CallByParam(lua.P{
+				Fn:      set,
+				Protect: true,
+			}, obj, String(name), value)
+
+			if err != nil && strings.Contains(err.Error(), "go panic") {
+				t.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,5 @@
+module github.com/mz-eco/lua
+
+go 1.24
+
+require github.com/yuin/gopher-lua v0.0.0-20180827083657-b942cacc89fe
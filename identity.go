@@ -0,0 +1,61 @@
+package lua
+
+import (
+	"runtime"
+	"sync"
+	"weak"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+// identities maps the go objects encoded as typed userdata in a vm to their
+// userdata, so an object gets the same userdata each time it is encoded. The
+// userdata are held weakly, an entry goes away once lua drops its userdata.
+type identities struct {
+	sync.Mutex
+	entries map[reference]weak.Pointer[lua.LUserData]
+}
+
+func (m *identities) load(ref reference) (*lua.LUserData, bool) {
+
+	m.Lock()
+	defer m.Unlock()
+
+	ud := m.entries[ref].Value()
+
+	return ud, ud != nil
+}
+
+func (m *identities) store(ref reference, ud *lua.LUserData) {
+
+	m.Lock()
+	defer m.Unlock()
+
+	if m.entries == nil {
+		m.entries = make(map[reference]weak.Pointer[lua.LUserData])
+	}
+
+	m.entries[ref] = weak.Make(ud)
+
+	runtime.AddCleanup(ud, m.remove, ref)
+}
+
+// remove runs once the userdata of ref is collected, the entry is kept if it
+// was replaced by a live one meanwhile.
+func (m *identities) remove(ref reference) {
+
+	m.Lock()
+	defer m.Unlock()
+
+	if wp, ok := m.entries[ref]; ok && wp.Value() == nil {
+		delete(m.entries, ref)
+	}
+}
+
+func (m *identities) clear() {
+
+	m.Lock()
+	defer m.Unlock()
+
+	m.entries = nil
+}
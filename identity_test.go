@@ -0,0 +1,112 @@
+package lua
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+type identityObject struct {
+	Typed
+	Name string
+}
+
+var (
+	identityType = &Type{
+		UUID: "9a4c1f7e-2b6d-4e8a-b3c5-7d1e0f9a2c64",
+		Name: "Identity",
+		Type: GoType((*identityObject)(nil)),
+	}
+)
+
+func identityCount(state *goState) int {
+
+	state.identities.Lock()
+	defer state.identities.Unlock()
+
+	return len(state.identities.entries)
+}
+
+func TestIdentityAcrossVMs(t *testing.T) {
+
+	var (
+		a, b = New(), New()
+		obj  = &identityObject{Name: "shared"}
+	)
+
+	defer Close(b)
+
+	Define(a, identityType)
+	Define(b, identityType)
+
+	encode := func(vm *VM) Value {
+
+		value, err := NewEncoder(vm, 0).Encode(obj)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return value
+	}
+
+	var (
+		a1, a2 = encode(a), encode(a)
+		b1     = encode(b)
+	)
+
+	if a1 != a2 {
+		t.Fatal("an object should keep its userdata within a vm")
+	}
+
+	if a1 == b1 {
+		t.Fatal("an object should get a userdata per vm")
+	}
+
+	b.SetGlobal("obj", b1)
+
+	if err := b.DoString(`assert(obj.Name == "shared")`); err != nil {
+		t.Fatal(err)
+	}
+
+	state := loadState(a)
+
+	if err := Close(a); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := identityCount(state); n != 0 {
+		t.Fatalf("%d identities left after close", n)
+	}
+
+	runtime.KeepAlive(a1)
+}
+
+func TestIdentityCollected(t *testing.T) {
+
+	var (
+		vm    = New()
+		state = loadState(vm)
+	)
+
+	defer Close(vm)
+
+	Define(vm, identityType)
+
+	func() {
+		for index := 0; index < 100; index++ {
+			if _, err := NewEncoder(vm, 0).Encode(&identityObject{}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}()
+
+	for index := 0; index < 100 && identityCount(state) > 0; index++ {
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+
+	if n := identityCount(state); n != 0 {
+		t.Fatalf("%d identities left after the userdata were collected", n)
+	}
+}
@@ -1,21 +1,40 @@
 package lua
 
 import (
+	"fmt"
 	R "reflect"
+	"strings"
+)
+
+type ReturnConvention int
+
+const (
+	ReturnRaise ReturnConvention = iota
+	ReturnNilError
+	ReturnErrorObject
 )
 
 type Invoker struct {
-	Name    string
-	GoFunc  interface{}
-	Caller  func(vm *VM) (R.Value, error)
-	CheckI  func(i []R.Type)
-	CheckO  func(o []R.Type)
-	Protect bool
+	Name     string
+	Doc      string
+	GoFunc   interface{}
+	Caller   func(vm *VM) (R.Value, error)
+	CheckI   func(i []R.Type)
+	CheckO   func(o []R.Type)
+	Protect  bool
+	Self     bool
+	Defaults []interface{}
+	Return   ReturnConvention
 
 	ft       R.Type
 	ret      int
 	hasError bool
 	caller   bool
+	context  bool
+	receiver bool
+	params   []R.Type
+	defaults []R.Value
+	required int
 }
 
 func (m *Invoker) iTypes() (types []R.Type) {
@@ -33,13 +52,161 @@ func (m *Invoker) oTypes() (types []R.Type) {
 
 	types = make([]R.Type, 0)
 
-	for index := 0; index < m.ft.NumIn(); index++ {
-		types = append(types, m.ft.In(index))
+	for index := 0; index < m.ft.NumOut(); index++ {
+		types = append(types, m.ft.Out(index))
 	}
 
 	return
 }
 
+// base is the stack index of the first argument, methods called with
+// obj:method(...) have their receiver at index 1.
+func (m *Invoker) base() int {
+
+	if m.Self {
+		return 2
+	}
+
+	return 1
+}
+
+func (m *Invoker) bind() {
+
+	var (
+		first = 0
+	)
+
+	if m.receiver {
+		first = 1
+	}
+
+	m.params = make([]R.Type, 0)
+
+	for index := first; index < m.ft.NumIn(); index++ {
+		m.params = append(m.params, m.ft.In(index))
+	}
+
+	m.caller = len(m.params) == 1 && m.params[0] == typeCall &&
+		m.ft.NumOut() == 1 && m.ft.Out(0) == typeCaller.Out(0)
+
+	// a leading *Call is given the current call rather than an argument
+	m.context = !m.caller && len(m.params) > 0 && m.params[0] == typeCall
+
+	if m.context {
+		m.params = m.params[1:]
+	}
+
+	var (
+		fixed = len(m.params)
+	)
+
+	if m.ft.IsVariadic() {
+		fixed = fixed - 1
+	}
+
+	if len(m.Defaults) > fixed {
+		panic(
+			fmt.Sprintf("%s has %d parameters, but %d defaults given", m.Name, fixed, len(m.Defaults)))
+	}
+
+	m.defaults = make([]R.Value, fixed)
+
+	for index, value := range m.Defaults {
+
+		var (
+			at = fixed - len(m.Defaults) + index
+			it = m.params[at]
+			dv = R.ValueOf(value)
+		)
+
+		if value == nil {
+			dv = R.Zero(it)
+		}
+
+		if !dv.Type().ConvertibleTo(it) {
+			panic(
+				fmt.Sprintf("%s default #%d <%s> is not assignable to <%s>", m.Name, at+1, dv.Type(), it))
+		}
+
+		m.defaults[at] = dv.Convert(it)
+	}
+
+	m.required = fixed
+
+	for m.required > 0 {
+
+		var (
+			at = m.required - 1
+		)
+
+		if !m.defaults[at].IsValid() && m.params[at].Kind() != R.Ptr {
+			break
+		}
+
+		m.required--
+	}
+}
+
+// Signature describes the function as seen from lua, optional parameters are
+// put in brackets and the trailing error is left out.
+func (m *Invoker) Signature() string {
+
+	if m.caller {
+		return m.Name + "(...)"
+	}
+
+	var (
+		params  = make([]string, 0)
+		results = make([]string, 0)
+		fixed   = len(m.defaults)
+	)
+
+	for index, it := range m.params {
+
+		var (
+			name = it.String()
+		)
+
+		switch {
+		case index >= fixed:
+			name = "..." + it.Elem().String()
+		case index >= m.required:
+			name = "[" + name + "]"
+		}
+
+		params = append(params, name)
+	}
+
+	for index := 0; index < m.ret; index++ {
+		results = append(results, m.ft.Out(index).String())
+	}
+
+	var (
+		signature = fmt.Sprintf("%s(%s)", m.Name, strings.Join(params, ", "))
+	)
+
+	if len(results) > 0 {
+		signature += " " + strings.Join(results, ", ")
+	}
+
+	return signature
+}
+
+func (m *Invoker) iValue(vm *VM, lv Value, it R.Type, opts *asOptions) (R.Value, bool) {
+
+	if lv == Nil {
+		return R.Zero(it), true
+	}
+
+	el := R.New(it)
+
+	if goValue(vm, el.Elem(), lv, opts) && opts.Ok() {
+		return el.Elem(), true
+	}
+
+	return R.Value{}, false
+}
+
 func (m *Invoker) iValues(vm *VM) (values []R.Value, index int, err error) {
 
 	values = make([]R.Value, 0)
@@ -53,30 +220,74 @@ func (m *Invoker) iValues(vm *VM) (values []R.Value, index int, err error) {
 			optional: false,
 			raise:    false,
 		}
+		base  = m.base()
+		fixed = len(m.defaults)
+		nargs = vm.GetTop() - base + 1
 	)
 
-	for index := 1; index < m.ft.NumIn(); index++ {
+	if nargs < 0 {
+		nargs = 0
+	}
+
+	// extra arguments are dropped as lua functions do
+	if nargs < m.required {
+		return nil, base + nargs, errArgCount{m.required, -1, true, nargs}
+	}
+
+	for index := 0; index < fixed; index++ {
+
+		var (
+			lv = vm.Get(base + index)
+			it = m.params[index]
+		)
+
+		if lv == Nil && m.defaults[index].IsValid() {
+			values = append(values, m.defaults[index])
+			continue
+		}
+
+		opts.field = fmt.Sprintf("%s(#%d)", m.Name, index+1)
+
+		value, ok := m.iValue(vm, lv, it, opts)
+
+		if !ok {
+			return nil, base + index, opts.GetError(
+				errTypeConvert{
+					opts.field,
+					lv.Type(),
+					it,
+				})
+		}
+
+		values = append(values, value)
+	}
+
+	if m.ft.IsVariadic() {
 
 		var (
-			lv = vm.Get(index + 1)
-			it = m.ft.In(index)
+			it = m.params[fixed].Elem()
 		)
 
-		if lv == Nil {
-			values = append(values, R.Zero(it))
-		} else {
-			el := R.New(it)
+		for index := base + fixed; index <= vm.GetTop(); index++ {
 
-			if goValue(vm, el.Elem(), lv, opts) && opts.Ok() {
-				values = append(values, el.Elem())
-			} else {
+			var (
+				lv = vm.Get(index)
+			)
+
+			opts.field = fmt.Sprintf("%s(#%d)", m.Name, index-base+1)
+
+			value, ok := m.iValue(vm, lv, it, opts)
+
+			if !ok {
 				return nil, index, opts.GetError(
 					errTypeConvert{
-						m.Name,
+						opts.field,
 						lv.Type(),
 						it,
 					})
 			}
+
+			values = append(values, value)
 		}
 	}
 
@@ -84,6 +295,12 @@ func (m *Invoker) iValues(vm *VM) (values []R.Value, index int, err error) {
 }
 
 func (m *Invoker) Invoke(vm *VM) int {
+	return protect(vm, m.Name, func() int {
+		return m.invoke(vm)
+	})
+}
+
+func (m *Invoker) invoke(vm *VM) int {
 
 	var (
 		fn, err = m.Caller(vm)
@@ -94,7 +311,7 @@ func (m *Invoker) Invoke(vm *VM) int {
 		return 0
 	}
 
-	call := newCall(vm, m.Name)
+	call := newCall(vm, m.Name, m.base())
 
 	if m.caller {
 		caller := fn.Interface().(func(call *Call) int)
@@ -108,16 +325,17 @@ func (m *Invoker) Invoke(vm *VM) int {
 		return 0
 	}
 
+	if m.context {
+		i = append([]R.Value{R.ValueOf(call)}, i...)
+	}
+
 	o := fn.Call(i)
 
 	if m.hasError {
 		err := o[m.ret]
 
 		if !err.IsNil() {
-			vm.RaiseError(
-				"%s", err.Interface(),
-			)
-			return 0
+			return m.fail(vm, err.Interface().(error))
 		}
 	}
 
@@ -125,6 +343,41 @@ func (m *Invoker) Invoke(vm *VM) int {
 
 }
 
+// fail reports the trailing error of a go function, a protected invoker
+// returns nil, err instead of raising unless another convention is set.
+func (m *Invoker) fail(vm *VM, err error) int {
+	return fail(vm, m.Return, m.Protect, m.ret, err)
+}
+
+func fail(vm *VM, convention ReturnConvention, protect bool, ret int, err error) int {
+
+	var (
+		values = 0
+	)
+
+	if protect && convention == ReturnRaise {
+		convention = ReturnNilError
+	}
+
+	if convention == ReturnRaise {
+		vm.RaiseError("%s", err)
+		return 0
+	}
+
+	for values = 0; values < ret || values == 0; values++ {
+		vm.Push(Nil)
+	}
+
+	switch convention {
+	case ReturnErrorObject:
+		vm.Push(ErrorValue(vm, err))
+	default:
+		vm.Push(String(err.Error()))
+	}
+
+	return values + 1
+}
+
 func VMGFunction(i *Invoker) GFunction {
 
 	if i.GoFunc == nil {
@@ -155,17 +408,16 @@ func VMGFunction(i *Invoker) GFunction {
 		}
 	}
 
-	if i.ft == typeCaller {
-		i.caller = true
+	if i.ft.Kind() != R.Func {
+		panic("GoFunc must type of func")
+	}
+
+	i.bind()
+
+	if i.caller {
 		return func(vm *VM) int {
 			return i.Invoke(vm)
 		}
-	} else {
-		i.caller = false
-	}
-
-	if i.ft.Kind() != R.Func {
-		panic("GoFunc must type of func")
 	}
 
 	if i.CheckI != nil {
@@ -181,10 +433,8 @@ func VMGFunction(i *Invoker) GFunction {
 
 	if i.ret > 0 {
 		if i.ft.Out(i.ret - 1).Implements(typeError) {
-			if !i.Protect {
-				i.ret = i.ret - 1
-				i.hasError = true
-			}
+			i.ret = i.ret - 1
+			i.hasError = true
 		}
 	}
 
@@ -226,10 +476,18 @@ func memberFunctions(value interface{}, cb func(v R.Value, m int, i *Invoker)) (
 	for index := 0; index < x.NumMethod(); index++ {
 		var (
 			m = x.Method(index)
+		)
+
+		if isReserved(x, m.Name) {
+			continue
+		}
+
+		var (
 			i = &Invoker{
-				Name:    m.Name,
-				GoFunc:  m.Type,
-				Protect: false,
+				Name:     m.Name,
+				GoFunc:   m.Type,
+				Protect:  false,
+				receiver: true,
 			}
 		)
 
@@ -0,0 +1,182 @@
+package lua
+
+import (
+	"strings"
+	"testing"
+)
+
+func invokerFunction(vm *VM, name string, fn interface{}) *Function {
+	return vm.NewFunction(VMGFunction(&Invoker{Name: name, GoFunc: fn}))
+}
+
+func TestInvokerArity(t *testing.T) {
+
+	var (
+		vm = New()
+	)
+
+	defer Close(vm)
+
+	vm.SetGlobal("add", invokerFunction(vm, "add", func(a int, b int) int {
+		return a + b
+	}))
+
+	tests := []struct {
+		script string
+		err    string
+	}{
+		{`assert(add(1, 2) == 3)`, ""},
+		{`assert(add(1, 2, 3) == 3)`, ""},
+		{`add(1)`, "want at least 2 arguments, given 1"},
+	}
+
+	for _, test := range tests {
+
+		err := vm.DoString(test.script)
+
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("%s: %s", test.script, err)
+		case test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)):
+			t.Errorf("%s: want error %q, got %v", test.script, test.err, err)
+		}
+	}
+}
+
+type invokerError struct {
+	code int
+}
+
+func (m *invokerError) Error() string {
+	return "boom"
+}
+
+func (m *invokerError) Code() int {
+	return m.code
+}
+
+func TestInvokerReturn(t *testing.T) {
+
+	var (
+		vm = New()
+	)
+
+	defer Close(vm)
+
+	tests := []struct {
+		convention ReturnConvention
+		script     string
+	}{
+		{ReturnRaise, `
+			local ok, err = pcall(fail)
+			assert(not ok and string.find(err, "boom"))
+		`},
+		{ReturnNilError, `
+			local x, err = fail()
+			assert(x == nil and err == "boom")
+		`},
+		{ReturnErrorObject, `
+			local x, err = fail()
+			assert(x == nil and type(err) == "userdata")
+			assert(err.code == 7 and err.message == "boom" and tostring(err) == "boom")
+			assert(err.other == nil)
+		`},
+	}
+
+	for _, test := range tests {
+
+		vm.SetGlobal("fail", vm.NewFunction(VMGFunction(&Invoker{
+			Name:   "fail",
+			Return: test.convention,
+			GoFunc: func() (int, error) {
+				return 0, &invokerError{7}
+			},
+		})))
+
+		if err := vm.DoString(test.script); err != nil {
+			t.Errorf("convention %d: %s", test.convention, err)
+		}
+	}
+}
+
+func TestInvokerPanic(t *testing.T) {
+
+	var (
+		vm     = New()
+		caught interface{}
+	)
+
+	defer Close(vm)
+
+	SetPanicHook(vm, func(vm *VM, name string, value interface{}, stack []byte) {
+		caught = value
+	})
+
+	vm.SetGlobal("explode", invokerFunction(vm, "explode", func() int {
+		panic("oops")
+	}))
+
+	err := vm.DoString(`
+		local ok, err = pcall(explode)
+		assert(not ok and string.find(err, "explode: go panic: oops", 1, true), err)
+		assert(pcall(explode) == false, "a panic should not break the vm")
+	`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if caught != "oops" {
+		t.Fatalf("panic hook got %v", caught)
+	}
+}
+
+func TestInvokerVariadic(t *testing.T) {
+
+	var (
+		vm = New()
+	)
+
+	defer Close(vm)
+
+	vm.SetGlobal("join", invokerFunction(vm, "join", func(sep string, parts ...string) string {
+		return strings.Join(parts, sep)
+	}))
+
+	vm.SetGlobal("sum", invokerFunction(vm, "sum", func(values ...int) int {
+
+		var (
+			n = 0
+		)
+
+		for _, x := range values {
+			n += x
+		}
+
+		return n
+	}))
+
+	tests := []struct {
+		script string
+		err    string
+	}{
+		{`assert(join(",") == "")`, ""},
+		{`assert(join(",", "a", "b", 3) == "a,b,3")`, ""},
+		{`assert(sum() == 0 and sum(1, 2, 3) == 6)`, ""},
+		{`join()`, "want at least 1 arguments, given 0"},
+		{`join(",", "a", {})`, "bad argument #3"},
+		{`sum(1, 2, "x")`, "bad argument #3"},
+	}
+
+	for _, test := range tests {
+
+		err := vm.DoString(test.script)
+
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("%s: %s", test.script, err)
+		case test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)):
+			t.Errorf("%s: want error %q, got %v", test.script, test.err, err)
+		}
+	}
+}
@@ -2,17 +2,24 @@ package std
 
 import "github.com/mz-eco/lua"
 
+//go:generate go run github.com/mz-eco/lua/cmd/luagen -type Members,Time
+
 type Members struct {
 	lua.ModuleMembers
 }
 
-var (
-	module = &lua.Module{
+func Loader() *lua.Module {
+
+	module := &lua.Module{
 		Name:    "std",
 		Members: &Members{},
 	}
-)
 
-func Loader() *lua.Module {
+	define(module)
+
 	return module
 }
+
+func init() {
+	lua.RegisterModule("std", Loader)
+}
@@ -0,0 +1,42 @@
+// Code generated by luagen. DO NOT EDIT.
+
+package std
+
+import (
+	"github.com/mz-eco/lua"
+)
+
+func (m *Members) LuaFunctions() map[string]lua.StaticFunction {
+	return map[string]lua.StaticFunction{
+		"Now": func(c *lua.Call) int {
+			c.CheckArgs(0, -1)
+			r0 := m.Now()
+			c.Return(r0)
+			return 1
+		},
+	}
+}
+
+func (*Members) LuaSignatures() map[string]string {
+	return map[string]string{
+		"Now": "Now() *std.Time",
+	}
+}
+
+func (*Time) LuaMethods() map[string]lua.StaticMethod {
+	return map[string]lua.StaticMethod{
+		"String": func(self interface{}, c *lua.Call) int {
+			m := self.(*Time)
+			c.CheckArgs(0, -1)
+			r0 := m.String()
+			c.Push(lua.String(r0))
+			return 1
+		},
+	}
+}
+
+func (*Time) LuaSignatures() map[string]string {
+	return map[string]string{
+		"String": "String() string",
+	}
+}
@@ -6,7 +6,7 @@ import (
 	"github.com/mz-eco/lua"
 )
 
-func init() {
+func define(module *lua.Module) {
 	module.Define(&lua.Type{
 		UUID: "7ba15f02-09c1-4b8d-9c34-7dcd29599dd4",
 		Name: "Time",
@@ -0,0 +1,294 @@
+package lua
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	R "reflect"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	ErrLoopClosed = errors.New("event loop is closed")
+)
+
+type CallMode int
+
+const (
+	CallBlocking CallMode = iota
+	CallAsync
+)
+
+// the states of a job posted by Call, the loop and the caller race to move
+// it out of jobQueued.
+const (
+	jobQueued int32 = iota
+	jobStarted
+	jobDropped
+)
+
+type outcome struct {
+	value interface{}
+	panic interface{}
+}
+
+// EventLoop is a mailbox for a vm, functions posted from any goroutine are
+// run one by one on the goroutine calling Run, the only one allowed to touch
+// the vm.
+type EventLoop struct {
+	vm   *VM
+	jobs chan func(vm *VM)
+	done chan struct{}
+	once sync.Once
+	lock sync.Mutex
+	err  error
+}
+
+func NewEventLoop(vm *VM) *EventLoop {
+	return &EventLoop{
+		vm:   vm,
+		jobs: make(chan func(vm *VM), 64),
+		done: make(chan struct{}),
+	}
+}
+
+func (m *EventLoop) VM() *VM {
+	return m.vm
+}
+
+// Post queues fn to be run by the loop, false is returned if the loop is
+// closed.
+func (m *EventLoop) Post(fn func(vm *VM)) bool {
+
+	select {
+	case <-m.done:
+		return false
+	default:
+	}
+
+	select {
+	case m.jobs <- fn:
+		return true
+	case <-m.done:
+		return false
+	}
+}
+
+// Call runs fn on the loop and waits for it, a panic of fn is raised again
+// on the calling goroutine. ErrLoopClosed is returned only when fn did not
+// run. Calling it from the loop goroutine (a go function called by lua for
+// instance) deadlocks.
+func (m *EventLoop) Call(fn func(vm *VM)) error {
+
+	_, err := m.call(func(vm *VM) interface{} {
+		fn(vm)
+		return nil
+	})
+
+	return err
+}
+
+func (m *EventLoop) call(fn func(vm *VM) interface{}) (interface{}, error) {
+
+	var (
+		state  int32
+		result = make(chan outcome, 1)
+	)
+
+	ok := m.Post(func(vm *VM) {
+
+		if !atomic.CompareAndSwapInt32(&state, jobQueued, jobStarted) {
+			return
+		}
+
+		var (
+			o outcome
+		)
+
+		defer func() {
+			o.panic = recover()
+			result <- o
+		}()
+
+		o.value = fn(vm)
+	})
+
+	if !ok {
+		return nil, ErrLoopClosed
+	}
+
+	select {
+	case o := <-result:
+		return o.settle()
+	case <-m.done:
+	}
+
+	// the loop is closed, fn is dropped unless it already started
+	if atomic.CompareAndSwapInt32(&state, jobQueued, jobDropped) {
+		return nil, ErrLoopClosed
+	}
+
+	o := <-result
+
+	return o.settle()
+}
+
+func (m *outcome) settle() (interface{}, error) {
+
+	if m.panic != nil {
+		panic(m.panic)
+	}
+
+	return m.value, nil
+}
+
+func (m *EventLoop) Close() {
+	m.once.Do(func() {
+		close(m.done)
+	})
+}
+
+func (m *EventLoop) fail(err error) {
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.err == nil {
+		m.err = err
+	}
+}
+
+func (m *EventLoop) run(fn func(vm *VM)) {
+
+	defer func() {
+		if r := recover(); r != nil {
+			if err, ok := r.(error); ok {
+				m.fail(err)
+			} else {
+				m.fail(fmt.Errorf("%v", r))
+			}
+		}
+	}()
+
+	fn(m.vm)
+}
+
+// Run executes the posted functions until ctx is done or the loop is closed.
+// A panic of a posted function does not stop the loop, the first one is
+// returned by Run. The loop is closed when Run returns, the functions still
+// queued are dropped and their callers get ErrLoopClosed.
+func (m *EventLoop) Run(ctx context.Context) error {
+
+	defer m.Close()
+
+	for {
+		select {
+		case fn := <-m.jobs:
+			m.run(fn)
+		case <-m.done:
+			return m.result(nil)
+		case <-ctx.Done():
+			return m.result(ctx.Err())
+		}
+	}
+}
+
+func (m *EventLoop) result(err error) error {
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.err != nil {
+		err = m.err
+		m.err = nil
+	}
+
+	return err
+}
+
+// Bind decodes the lua function src into the go func pointed by value, the
+// resulting func can be called from any goroutine. In CallBlocking mode the
+// caller waits for the results, in CallAsync mode the call is queued and
+// zero values are returned at once. Bind must be called by the goroutine
+// owning the vm.
+func (m *EventLoop) Bind(src Value, value interface{}, mode CallMode) error {
+
+	var (
+		v = R.ValueOf(value)
+	)
+
+	if v.Kind() != R.Ptr || v.Elem().Kind() != R.Func {
+		return errors.New("event loop bind must give a func pointer")
+	}
+
+	var (
+		fv = R.New(v.Elem().Type())
+	)
+
+	if err := As(m.vm, src, fv.Interface()); err != nil {
+		return err
+	}
+
+	v.Elem().Set(m.wrap(fv.Elem(), mode))
+
+	return nil
+}
+
+func (m *EventLoop) wrap(fn R.Value, mode CallMode) R.Value {
+
+	var (
+		t        = fn.Type()
+		no       = t.NumOut()
+		hasError = no > 0 && t.Out(no-1) == typeError
+	)
+
+	call := func(args []R.Value) []R.Value {
+		if t.IsVariadic() {
+			return fn.CallSlice(args)
+		}
+		return fn.Call(args)
+	}
+
+	return R.MakeFunc(t, func(args []R.Value) (results []R.Value) {
+
+		results = make([]R.Value, no)
+
+		for index := 0; index < no; index++ {
+			results[index] = R.Zero(t.Out(index))
+		}
+
+		if mode == CallAsync {
+			posted := m.Post(func(vm *VM) {
+				out := call(args)
+
+				if hasError && !out[no-1].IsNil() {
+					m.fail(out[no-1].Interface().(error))
+				}
+			})
+
+			if !posted && hasError {
+				err := ErrLoopClosed
+				results[no-1] = R.ValueOf(&err).Elem()
+			}
+
+			return
+		}
+
+		out, err := m.call(func(vm *VM) interface{} {
+			return call(args)
+		})
+
+		if err == nil {
+			return out.([]R.Value)
+		}
+
+		if !hasError {
+			panic(err)
+		}
+
+		results[no-1] = R.ValueOf(&err).Elem()
+
+		return
+	})
+}
@@ -0,0 +1,146 @@
+package lua
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestEventLoopBind(t *testing.T) {
+
+	var (
+		vm     = New()
+		loop   = NewEventLoop(vm)
+		add    func(int, int) (int, error)
+		notify func(string) error
+		result = make(chan error, 1)
+	)
+
+	defer Close(vm)
+
+	err := vm.DoString(`
+		calls = 0
+		function add(a, b) calls = calls + 1 return a + b end
+		function notify(s) error("notify " .. s) end
+	`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := loop.Bind(vm.GetGlobal("add"), &add, CallBlocking); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := loop.Bind(vm.GetGlobal("notify"), &notify, CallAsync); err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		result <- loop.Run(context.Background())
+	}()
+
+	if x, err := add(1, 2); err != nil || x != 3 {
+		t.Fatalf("add returned %d %v", x, err)
+	}
+
+	if err := notify("x"); err != nil {
+		t.Fatalf("async call returned %v", err)
+	}
+
+	var (
+		calls Value
+	)
+
+	err = loop.Call(func(vm *VM) {
+		calls = vm.GetGlobal("calls")
+	})
+
+	if err != nil || calls != Number(1) {
+		t.Fatalf("call returned %v %v", calls, err)
+	}
+
+	loop.Close()
+
+	if err := <-result; err == nil {
+		t.Fatal("run should return the error of the async call")
+	}
+}
+
+func TestEventLoopShutdown(t *testing.T) {
+
+	var (
+		vm          = New()
+		loop        = NewEventLoop(vm)
+		ping        func() error
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+
+	defer Close(vm)
+
+	if err := vm.DoString(`function ping() end`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := loop.Bind(vm.GetGlobal("ping"), &ping, CallBlocking); err != nil {
+		t.Fatal(err)
+	}
+
+	cancel()
+
+	if err := loop.Run(ctx); err != context.Canceled {
+		t.Fatalf("run returned %v", err)
+	}
+
+	for index := 0; index < 100; index++ {
+		if loop.Post(func(vm *VM) {}) {
+			t.Fatal("post should fail once run returned")
+		}
+	}
+
+	within(t, "call after shutdown", func() {
+		if err := loop.Call(func(vm *VM) {}); !errors.Is(err, ErrLoopClosed) {
+			t.Errorf("call returned %v", err)
+		}
+
+		if err := ping(); !errors.Is(err, ErrLoopClosed) {
+			t.Errorf("bound call returned %v", err)
+		}
+	})
+}
+
+func TestEventLoopCloseWhileCalling(t *testing.T) {
+
+	var (
+		vm = New()
+	)
+
+	defer Close(vm)
+
+	if err := vm.DoString(`function stop(n) close() return n end`); err != nil {
+		t.Fatal(err)
+	}
+
+	for index := 0; index < 100; index++ {
+
+		var (
+			loop = NewEventLoop(vm)
+			stop func(int) (int, error)
+		)
+
+		vm.SetGlobal("close", vm.NewFunction(func(vm *VM) int {
+			loop.Close()
+			return 0
+		}))
+
+		if err := loop.Bind(vm.GetGlobal("stop"), &stop, CallBlocking); err != nil {
+			t.Fatal(err)
+		}
+
+		go loop.Run(context.Background())
+
+		if x, err := stop(index); err != nil || x != index {
+			t.Fatalf("a call closing the loop returned %d %v", x, err)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package lua
+
+import (
+	"testing"
+)
+
+type handlers struct {
+	Dot   func(int) int
+	Colon func(int) int `lua:"method"`
+}
+
+func TestMethodTag(t *testing.T) {
+
+	var (
+		vm = New()
+		h  handlers
+	)
+
+	defer Close(vm)
+
+	err := vm.DoString(`
+		obj = { n = 10 }
+		function obj.Dot(x) return x * 2 end
+		function obj:Colon(x) return self.n + x end
+	`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := As(vm, vm.GetGlobal("obj"), &h); err != nil {
+		t.Fatal(err)
+	}
+
+	if x := h.Dot(4); x != 8 {
+		t.Fatalf("dot call returned %d", x)
+	}
+
+	if x := h.Colon(4); x != 14 {
+		t.Fatalf("colon call returned %d", x)
+	}
+}
+
+func TestBindMethod(t *testing.T) {
+
+	var (
+		vm     = New()
+		handle func(string) string
+		plain  func(string) string
+	)
+
+	defer Close(vm)
+
+	err := vm.DoString(`
+		obj = { prefix = "> " }
+		function obj:handle(s) return self.prefix .. s end
+	`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := BindMethod(vm, vm.GetGlobal("obj"), "handle", &handle); err != nil {
+		t.Fatal(err)
+	}
+
+	if x := handle("hi"); x != "> hi" {
+		t.Fatalf("method call returned %q", x)
+	}
+
+	if err := As(vm, vm.GetField(vm.GetGlobal("obj"), "handle"), &plain); err != nil {
+		t.Fatal(err)
+	}
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("calling a method without self should fail")
+			}
+		}()
+
+		plain("hi")
+	}()
+
+	if err := BindMethod(vm, vm.GetGlobal("obj"), "missing", &handle); err == nil {
+		t.Fatal("binding a missing method should fail")
+	}
+}
@@ -1,9 +1,17 @@
 package lua
 
 import (
+	"fmt"
+	"io"
 	R "reflect"
+
+	lua "github.com/yuin/gopher-lua"
 )
 
+// ModuleMembers marks the struct whose methods are the functions of a
+// module. A method needing the calling thread, a coroutine maybe, takes a
+// leading *Call parameter, it is given the current call instead of an
+// argument.
 type ModuleMembers struct {
 }
 
@@ -11,51 +19,301 @@ func (m *ModuleMembers) module() {
 
 }
 
+// ModuleLoader is called once per LoadModule, it should return a new module
+// so every vm gets its own members. Members implementing io.Closer are closed
+// by Close.
 type ModuleLoader func() *Module
 
 type Module struct {
-	Name    string
-	Members moduleMembers
-	types   []*Type
+	Name     string
+	Doc      string
+	Docs     map[string]string
+	Members  moduleMembers
+	Return   ReturnConvention
+	Protect  bool
+	Reflect  bool
+	Requires []ModuleLoader
+	types    []*Type
+	values   map[string]interface{}
+	lazies   map[string]func() interface{}
+	children []*Module
 }
 
 func (m *Module) Define(x *Type) {
 	m.types = append(m.types, x)
 }
 
-func LoadModule(vm *VM, loader ModuleLoader) {
+// Set exports x as the field name of the module, x is encoded through
+// Encoder when the module is required.
+func (m *Module) Set(name string, x interface{}) {
+
+	if m.values == nil {
+		m.values = make(map[string]interface{})
+	}
+
+	m.values[name] = x
+}
+
+// Lazy exports the field name computed by fn on its first access.
+func (m *Module) Lazy(name string, fn func() interface{}) {
+
+	if m.lazies == nil {
+		m.lazies = make(map[string]func() interface{})
+	}
+
+	m.lazies[name] = fn
+}
+
+// Submodule nests child as the field child.Name of the module.
+func (m *Module) Submodule(child *Module) {
+	m.children = append(m.children, child)
+}
+
+func (m *Module) attach(vm *VM) {
+
+	if closer, ok := m.Members.(io.Closer); ok {
+		onClose(vm, closer.Close)
+	}
+
+	for _, child := range m.children {
+		child.attach(vm)
+	}
+}
+
+// release closes the members of a module which was never loaded.
+func (m *Module) release() {
+
+	if closer, ok := m.Members.(io.Closer); ok {
+		closer.Close()
+	}
+
+	for _, child := range m.children {
+		child.release()
+	}
+}
+
+// table builds the module table, path is the name the module is required
+// under, the one shown by help and errors.
+func (m *Module) table(vm *VM, path string) (*Table, error) {
 
 	var (
-		m = loader()
+		tbl     = vm.NewTable()
+		encoder = NewEncoder(vm, 0)
 	)
 
-	vm.PreloadModule(
-		m.Name, func(vm *VM) int {
+	for _, x := range m.types {
+		Define(vm, x)
+	}
+
+	if static, ok := m.Members.(StaticMembers); ok && !m.Reflect {
+
+		var (
+			state = loadState(vm)
+		)
+
+		for name, fn := range static.LuaFunctions() {
+			tbl.RawSetString(name, vm.NewFunction(
+				staticFunction(name, m.Return, m.Protect, false, fn)))
+		}
+
+		for name, signature := range signatures(m.Members, path+".") {
+			state.describe(tbl.RawGetString(name), signature)
+		}
+	} else if m.Members != nil {
+
+		var (
+			invokers = make(map[string]*Invoker)
+			state    = loadState(vm)
+		)
+
+		members := memberFunctions(
+			m.Members,
+			func(v R.Value, index int, i *Invoker) {
+				i.Return = m.Return
+				i.Protect = m.Protect
+				i.Caller = func(vm *VM) (R.Value, error) {
+					return v.Method(index), nil
+				}
+				invokers[i.Name] = i
+			},
+		)
+
+		vm.SetFuncs(tbl, members)
+
+		for name, i := range invokers {
+			state.describe(tbl.RawGetString(name), path+"."+i.Signature())
+		}
+	}
+
+	for name, x := range m.values {
+
+		value, err := encoder.Encode(x)
+
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %s", path, name, err)
+		}
+
+		tbl.RawSetString(name, value)
+	}
+
+	for _, child := range m.children {
+
+		sub, err := child.table(vm, path+"."+child.Name)
+
+		if err != nil {
+			return nil, err
+		}
+
+		tbl.RawSetString(child.Name, sub)
+	}
+
+	if len(m.lazies) > 0 {
+
+		var (
+			mt = vm.NewTable()
+		)
+
+		index := func(vm *VM) int {
 
 			var (
-				tbl = vm.NewTable()
+				name = vm.CheckString(2)
 			)
 
-			for _, x := range m.types {
-				Define(vm, x)
-			}
+			fn, ok := m.lazies[name]
 
-			if m.Members != nil {
+			if !ok {
+				vm.Push(Nil)
+				return 1
+			}
 
-				members := memberFunctions(
-					m.Members,
-					func(v R.Value, index int, i *Invoker) {
-						i.Caller = func(vm *VM) (R.Value, error) {
-							return v.Method(index), nil
-						}
-					},
-				)
+			value, err := NewEncoder(vm, 0).Encode(fn())
 
-				vm.SetFuncs(tbl, members)
+			if err != nil {
+				vm.RaiseError("%s.%s: %s", path, name, err)
+				return 0
 			}
 
-			vm.Push(tbl)
+			tbl.RawSetString(name, value)
+			vm.Push(value)
 
 			return 1
-		})
+		}
+
+		vm.SetField(mt, "__index", vm.NewFunction(func(vm *VM) int {
+			return protect(vm, "__index", func() int {
+				return index(vm)
+			})
+		}))
+
+		vm.SetMetatable(tbl, mt)
+	}
+
+	return tbl, nil
+}
+
+type LoadOptions struct {
+	// Alias is the name the module is registered under, Module.Name if empty.
+	Alias string
+	// Global also installs the module table as a global variable.
+	Global bool
+}
+
+func LoadModule(vm *VM, loader ModuleLoader) {
+	LoadModuleWith(vm, loader, LoadOptions{})
+}
+
+// LoadModuleWith registers the module of loader for require, the modules it
+// requires are registered first and required before it is. A name which is
+// already registered is left untouched.
+func LoadModuleWith(vm *VM, loader ModuleLoader, options LoadOptions) error {
+	return loadModule(vm, loader(), options)
+}
+
+func loadModule(vm *VM, m *Module, options LoadOptions) error {
+
+	var (
+		name  = m.Name
+		state = loadState(vm)
+	)
+
+	if options.Alias != "" {
+		name = options.Alias
+	}
+
+	if state.modules == nil {
+		state.modules = make(map[string]*Module)
+	}
+
+	if _, ok := state.modules[name]; !ok {
+
+		state.modules[name] = m
+		m.attach(vm)
+
+		var (
+			requires = make([]string, 0, len(m.Requires))
+		)
+
+		for _, require := range m.Requires {
+
+			dependency := require()
+			requires = append(requires, dependency.Name)
+
+			if err := loadModule(vm, dependency, LoadOptions{}); err != nil {
+				return err
+			}
+		}
+
+		vm.PreloadModule(
+			name, func(vm *VM) int {
+
+				for _, require := range requires {
+					requireModule(vm, require)
+				}
+
+				tbl, err := m.table(vm, name)
+
+				if err != nil {
+					vm.RaiseError("%s", err)
+					return 0
+				}
+
+				vm.Push(tbl)
+
+				return 1
+			})
+	} else {
+		m.release()
+	}
+
+	if !options.Global {
+		return nil
+	}
+
+	var (
+		top = vm.GetTop()
+	)
+
+	defer vm.SetTop(top)
+
+	err := vm.CallByParam(
+		lua.P{
+			Fn:      vm.GetGlobal("require"),
+			NRet:    1,
+			Protect: true,
+		}, String(name))
+
+	if err != nil {
+		return err
+	}
+
+	vm.SetGlobal(name, vm.Get(-1))
+
+	return nil
+}
+
+func requireModule(vm *VM, name string) {
+
+	vm.Push(vm.GetGlobal("require"))
+	vm.Push(String(name))
+	vm.Call(1, 0)
 }
@@ -0,0 +1,135 @@
+package lua
+
+import (
+	"strings"
+	"testing"
+)
+
+type fieldMembers struct {
+	ModuleMembers
+}
+
+func (m *fieldMembers) Twice(x int) int {
+	return x * 2
+}
+
+func TestModuleFields(t *testing.T) {
+
+	var (
+		vm    = New()
+		calls = 0
+	)
+
+	defer Close(vm)
+
+	LoadModule(vm, func() *Module {
+
+		var (
+			m     = &Module{Name: "fields", Members: &fieldMembers{}}
+			child = &Module{Name: "time", Members: &fieldMembers{}}
+		)
+
+		m.Set("VERSION", "1.2")
+		m.Set("LIMITS", map[string]int{"max": 10})
+
+		m.Lazy("counted", func() interface{} {
+			calls++
+			return calls
+		})
+
+		m.Lazy("broken", func() interface{} {
+			return complex(1, 2)
+		})
+
+		child.Set("ZONE", "UTC")
+		m.Submodule(child)
+
+		return m
+	})
+
+	tests := []struct {
+		script string
+		err    string
+	}{
+		{`
+			local m = require("fields")
+			assert(m.VERSION == "1.2" and m.LIMITS.max == 10)
+			assert(m.Twice(2) == 4)
+		`, ""},
+		{`
+			local m = require("fields")
+			assert(m.counted == 1 and m.counted == 1)
+			assert(m.missing == nil)
+		`, ""},
+		{`
+			local m = require("fields")
+			assert(m.time.ZONE == "UTC" and m.time.Twice(3) == 6)
+		`, ""},
+		{`return require("fields").broken`, "fields.broken:"},
+	}
+
+	for _, test := range tests {
+
+		err := vm.DoString(test.script)
+
+		switch {
+		case test.err == "" && err != nil:
+			t.Errorf("%s: %s", test.script, err)
+		case test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)):
+			t.Errorf("%s: want error %q, got %v", test.script, test.err, err)
+		}
+	}
+
+	if calls != 1 {
+		t.Fatalf("lazy field computed %d times", calls)
+	}
+}
+
+func TestModuleLoadOptions(t *testing.T) {
+
+	var (
+		vm = New()
+	)
+
+	defer Close(vm)
+
+	base := func() *Module {
+		m := &Module{Name: "base"}
+		m.Set("loaded", true)
+		return m
+	}
+
+	app := func() *Module {
+		m := &Module{Name: "app", Requires: []ModuleLoader{base}}
+		m.Set("NAME", "app")
+		return m
+	}
+
+	if err := LoadModuleWith(vm, app, LoadOptions{Alias: "main", Global: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := LoadModuleWith(vm, app, LoadOptions{Alias: "main"}); err != nil {
+		t.Fatal(err)
+	}
+
+	err := vm.DoString(`
+		assert(main.NAME == "app", "the module should be a global")
+		assert(rawequal(require("main"), main))
+		assert(package.loaded.base, "dependencies should be required first")
+		assert(require("base").loaded)
+		assert(app == nil)
+	`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := LoadModuleWith(vm, func() *Module {
+		m := &Module{Name: "bad"}
+		m.Set("BROKEN", complex(1, 2))
+		return m
+	}, LoadOptions{Global: true}); err == nil {
+		t.Fatal("a module failing to build should fail to load as a global")
+	}
+}
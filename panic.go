@@ -0,0 +1,50 @@
+package lua
+
+import (
+	"runtime/debug"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+type PanicHook func(vm *VM, name string, value interface{}, stack []byte)
+
+func SetPanicHook(vm *VM, hook PanicHook) {
+	loadState(vm).panicHook = hook
+}
+
+// protect runs a go function bound to lua, a panic is turned into a lua
+// error, lua errors raised by fn are passed through untouched.
+func protect(vm *VM, name string, fn func() int) (n int) {
+
+	defer func() {
+
+		var (
+			r = recover()
+		)
+
+		if r == nil {
+			return
+		}
+
+		if _, ok := r.(*lua.ApiError); ok {
+			panic(r)
+		}
+
+		var (
+			stack = debug.Stack()
+			hook  = loadState(vm).panicHook
+		)
+
+		if hook != nil {
+			hook(vm, name, r, stack)
+		}
+
+		if vm.Options.IncludeGoStackTrace {
+			vm.RaiseError("%s: go panic: %v\n%s", name, r, stack)
+		} else {
+			vm.RaiseError("%s: go panic: %v", name, r)
+		}
+	}()
+
+	return fn()
+}
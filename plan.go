@@ -0,0 +1,130 @@
+package lua
+
+import (
+	R "reflect"
+	"sync"
+)
+
+type fieldPlan struct {
+	index    int
+	name     string
+	tag      tags
+	t        R.Type
+	exported bool
+	class    bool
+}
+
+// structPlan is the field layout of a struct type, computed once per type
+// and shared by every vm.
+type structPlan struct {
+	fields []fieldPlan
+	byName map[string]int
+}
+
+var (
+	plans sync.Map
+)
+
+func planOf(t R.Type) *structPlan {
+
+	if t.Kind() == R.Ptr {
+		t = t.Elem()
+	}
+
+	if plan, ok := plans.Load(t); ok {
+		return plan.(*structPlan)
+	}
+
+	var (
+		plan = &structPlan{
+			fields: make([]fieldPlan, 0, t.NumField()),
+			byName: make(map[string]int),
+		}
+	)
+
+	for index := 0; index < t.NumField(); index++ {
+
+		var (
+			ft = t.Field(index)
+			fp = fieldPlan{
+				index:    index,
+				name:     ft.Name,
+				tag:      makeTags(ft),
+				t:        ft.Type,
+				exported: ft.PkgPath == "",
+				class:    ft.Type == TableMappingClass,
+			}
+		)
+
+		if fp.exported && !fp.tag.skip && !fp.class {
+			plan.byName[fp.name] = len(plan.fields)
+		}
+
+		plan.fields = append(plan.fields, fp)
+	}
+
+	actual, _ := plans.LoadOrStore(t, plan)
+
+	return actual.(*structPlan)
+}
+
+// field returns the exported, not skipped field called name.
+func (m *structPlan) field(name string) (fieldPlan, bool) {
+
+	index, ok := m.byName[name]
+
+	if !ok {
+		return fieldPlan{}, false
+	}
+
+	return m.fields[index], true
+}
+
+// typePlan caches the interfaces a type implements, they decide how values of
+// the type are converted.
+type typePlan struct {
+	value   bool
+	class   bool
+	mapping bool
+	// struct whose pointer implements TableMapping
+	mappingAddr bool
+}
+
+var (
+	typePlans sync.Map
+)
+
+func typePlanOf(t R.Type) *typePlan {
+
+	if plan, ok := typePlans.Load(t); ok {
+		return plan.(*typePlan)
+	}
+
+	var (
+		plan = &typePlan{
+			value:   t.Implements(typeValue),
+			class:   t.Implements(typeClass),
+			mapping: t.Implements(typeTableMapping),
+		}
+	)
+
+	plan.mappingAddr = t.Kind() == R.Struct && R.PtrTo(t).Implements(typeTableMapping)
+
+	actual, _ := typePlans.LoadOrStore(t, plan)
+
+	return actual.(*typePlan)
+}
+
+// dynamicType is the type of the value held by src, nil for a nil interface.
+func dynamicType(src R.Value) R.Type {
+
+	if src.Kind() != R.Interface {
+		return src.Type()
+	}
+
+	if src.IsNil() {
+		return nil
+	}
+
+	return src.Elem().Type()
+}
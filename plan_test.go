@@ -0,0 +1,177 @@
+package lua
+
+import (
+	"testing"
+)
+
+type benchLeaf struct {
+	TableMapping
+	Name  string
+	Value float64
+	Flag  bool `lua:"option"`
+}
+
+type benchNode struct {
+	TableMapping
+	ID     int
+	Title  string
+	Leaf   *benchLeaf
+	Leaves []*benchLeaf
+	Tags   map[string]int
+}
+
+func benchTree() *benchNode {
+
+	var (
+		node = &benchNode{
+			ID:    1,
+			Title: "root",
+			Leaf:  &benchLeaf{Name: "leaf", Value: 1.5},
+			Tags:  map[string]int{"a": 1, "b": 2},
+		}
+	)
+
+	for index := 0; index < 32; index++ {
+		node.Leaves = append(node.Leaves, &benchLeaf{Name: "child", Value: float64(index)})
+	}
+
+	return node
+}
+
+func benchInts() []int {
+
+	var (
+		values = make([]int, 4096)
+	)
+
+	for index := range values {
+		values[index] = index
+	}
+
+	return values
+}
+
+func BenchmarkEncodeNested(b *testing.B) {
+
+	var (
+		vm      = New()
+		node    = benchTree()
+		encoder = NewEncoder(vm, FlagSkipMethod)
+	)
+
+	defer Close(vm)
+
+	b.ReportAllocs()
+
+	for index := 0; index < b.N; index++ {
+		if _, err := encoder.Encode(node); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeNested(b *testing.B) {
+
+	var (
+		vm = New()
+	)
+
+	defer Close(vm)
+
+	value, err := NewEncoder(vm, FlagSkipMethod).Encode(benchTree())
+
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for index := 0; index < b.N; index++ {
+
+		var (
+			node benchNode
+		)
+
+		if err := NewDeocder(vm, FlagSkipMethod).Decode(value, &node); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkAsNested(b *testing.B) {
+
+	var (
+		vm = New()
+	)
+
+	defer Close(vm)
+
+	value, err := NewEncoder(vm, FlagSkipMethod).Encode(benchTree())
+
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for index := 0; index < b.N; index++ {
+
+		var (
+			node benchNode
+		)
+
+		if err := As(vm, value, &node); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncodeSlice(b *testing.B) {
+
+	var (
+		vm      = New()
+		values  = benchInts()
+		encoder = NewEncoder(vm, FlagSkipMethod)
+	)
+
+	defer Close(vm)
+
+	b.ReportAllocs()
+
+	for index := 0; index < b.N; index++ {
+		if _, err := encoder.Encode(values); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDecodeSlice(b *testing.B) {
+
+	var (
+		vm = New()
+	)
+
+	defer Close(vm)
+
+	value, err := NewEncoder(vm, FlagSkipMethod).Encode(benchInts())
+
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for index := 0; index < b.N; index++ {
+
+		var (
+			values []int
+		)
+
+		if err := NewDeocder(vm, FlagSkipMethod).Decode(value, &values); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
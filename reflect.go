@@ -18,6 +18,8 @@ type asOptions struct {
 	error    error
 	optional bool
 	raise    bool
+	method   bool
+	tracker  *tracker
 }
 
 func (m *asOptions) Save() asOptions {
@@ -27,6 +29,7 @@ func (m *asOptions) Save() asOptions {
 		base:     m.base,
 		optional: m.optional,
 		raise:    m.raise,
+		method:   m.method,
 	}
 }
 
@@ -36,6 +39,26 @@ func (m *asOptions) Load(opts asOptions) {
 	m.base = opts.base
 	m.optional = opts.optional
 	m.raise = opts.raise
+	m.method = opts.method
+}
+
+func (m *asOptions) track() *tracker {
+
+	if m.tracker == nil {
+		m.tracker = newTracker(DefaultDecodeOptions)
+	}
+
+	return m.tracker
+}
+
+func (m *asOptions) visit(src Value) bool {
+
+	if !m.track().visit(src) {
+		m.Error(&errorCycle{m.field})
+		return false
+	}
+
+	return true
 }
 
 func (m *asOptions) Ok() bool {
@@ -64,7 +87,7 @@ func (m *asOptions) Error(err error) bool {
 	m.error = err
 
 	if m.error != nil && m.raise {
-		m.vm.RaiseError(err.Error())
+		m.vm.RaiseError("%s", err)
 	}
 	return true
 }
@@ -127,6 +150,16 @@ func goValuePod(vm *VM, v R.Value, src Value, opts *asOptions) (ok bool) {
 				if err != nil {
 					opts.Error(err)
 				}
+			case lua.LTBool:
+				if kind != R.Bool {
+					opts.Error(typeError)
+					return nil
+				}
+
+				boolean = lua.LVAsBool(src)
+			default:
+				opts.Error(typeError)
+				return nil
 			}
 
 			switch kind {
@@ -186,11 +219,11 @@ func goValuePod(vm *VM, v R.Value, src Value, opts *asOptions) (ok bool) {
 		case lua.LTTable:
 			opts.skipFunc = true
 			var (
-				values = make(map[interface{}]interface{})
+				values = R.New(typeInterfaceMap).Elem()
 			)
 
-			if goValue(vm, R.ValueOf(values), src, opts) && opts.Ok() {
-				i = values
+			if goValue(vm, values, src, opts) && opts.Ok() {
+				i = values.Interface()
 			}
 
 		default:
@@ -201,7 +234,11 @@ func goValuePod(vm *VM, v R.Value, src Value, opts *asOptions) (ok bool) {
 			return false
 		}
 
-		v.Set(R.ValueOf(i))
+		if i == nil {
+			v.Set(R.Zero(t))
+		} else {
+			v.Set(R.ValueOf(i))
+		}
 	case R.Slice:
 
 		if lt != lua.LTTable {
@@ -209,33 +246,38 @@ func goValuePod(vm *VM, v R.Value, src Value, opts *asOptions) (ok bool) {
 			break
 		}
 
+		if !opts.visit(src) {
+			break
+		}
+
+		defer opts.tracker.release(src)
+
 		var (
-			tbl   = src.(*Table)
-			et    = t.Elem()
-			slice = R.MakeSlice(t, 0, 0)
-			osf   = opts.skipFunc
+			tbl    = src.(*Table)
+			n, key = sequence(tbl)
+			slice  = R.MakeSlice(t, n, n)
+			osf    = opts.skipFunc
 		)
-		opts.skipFunc = true
 
-		tbl.ForEach(func(_ lua.LValue, value lua.LValue) {
+		if key != Nil && opts.track().options.Sequence == SequenceReject {
+			opts.Error(&errorSequence{key})
+			break
+		}
 
-			if opts.error != nil {
-				return
-			}
+		opts.skipFunc = true
+
+		for index := 0; index < n && opts.Ok(); index++ {
 
 			var (
-				ptr = R.New(et)
+				value = tbl.RawGetInt(index + 1)
 			)
 
-			if goValue(vm, ptr.Elem(), value, opts) {
-
-				if opts.error != nil {
-					return
-				}
-
-				slice = R.Append(slice, ptr.Elem())
+			if value == Nil {
+				continue
 			}
-		})
+
+			goValue(vm, slice.Index(index), value, opts)
+		}
 		opts.skipFunc = osf
 
 		v.Set(slice)
@@ -256,6 +298,14 @@ func goValuePod(vm *VM, v R.Value, src Value, opts *asOptions) (ok bool) {
 			osf = opts.skipFunc
 		)
 
+		opts.track().remember(src, m)
+
+		if !opts.visit(src) {
+			return
+		}
+
+		defer opts.tracker.release(src)
+
 		opts.skipFunc = true
 		tbl.ForEach(func(key lua.LValue, value lua.LValue) {
 
@@ -277,6 +327,8 @@ func goValuePod(vm *VM, v R.Value, src Value, opts *asOptions) (ok bool) {
 			ev = R.New(t.Elem())
 		)
 
+		opts.track().remember(src, ev)
+
 		ok = goValue(vm, ev.Elem(), src, opts)
 
 		if ok && opts.Ok() {
@@ -290,23 +342,42 @@ func goValuePod(vm *VM, v R.Value, src Value, opts *asOptions) (ok bool) {
 }
 
 var (
-	typeInterface = R.TypeOf((*interface{})(nil)).Elem()
-	typeError     = R.TypeOf((*error)(nil)).Elem()
+	typeInterface    = R.TypeOf((*interface{})(nil)).Elem()
+	typeInterfaceMap = R.TypeOf((map[interface{}]interface{})(nil))
+	typeError        = R.TypeOf((*error)(nil)).Elem()
 )
 
+// CallbackHook receives the errors of lua callbacks decoded to go funcs
+// without an error result, instead of a panic. It is looked up on failure,
+// so it also applies to the callbacks decoded before it was set.
+type CallbackHook func(vm *VM, fn *Function, err error)
+
+func SetCallbackHook(vm *VM, hook CallbackHook) {
+	loadState(vm).callbackHook = hook
+}
+
 func makeFunc(vm *VM, v R.Value, src, self Value) R.Value {
 
+	var (
+		t        = v.Type()
+		fn       = src.(*Function)
+		no       = t.NumOut()
+		hasError = no > 0 && t.Out(no-1) == typeError
+		ret      = no
+	)
+
+	if hasError {
+		ret = no - 1
+	}
+
 	return R.MakeFunc(
-		v.Type(),
+		t,
 		func(args []R.Value) (results []R.Value) {
 
 			var (
 				i    = make([]Value, 0)
-				t    = v.Type()
-				fn   = src.(*Function)
-				no   = t.NumOut()
-				ret  = no - 1
 				opts = &asOptions{
+					vm:       vm,
 					field:    t.Name(),
 					skipFunc: true,
 					base:     src,
@@ -324,26 +395,50 @@ func makeFunc(vm *VM, v R.Value, src, self Value) R.Value {
 				results[index] = R.Zero(t.Out(index))
 			}
 
+			fail := func(err error) []R.Value {
+
+				if hasError {
+					results[ret] = R.ValueOf(&err).Elem()
+					return results
+				}
+
+				if hook := loadState(vm).callbackHook; hook != nil {
+					hook(vm, fn, err)
+					return results
+				}
+
+				panic(err)
+			}
+
 			if self != Nil {
 				i = append(i, self)
 			}
 
+			if t.IsVariadic() && len(args) > 0 {
+				var (
+					last = args[len(args)-1]
+				)
+
+				args = args[:len(args)-1]
+
+				for index := 0; index < last.Len(); index++ {
+					args = append(args, last.Index(index))
+				}
+			}
+
 			for index, arg := range args {
 
 				value, err = encoder.Encode(arg)
 
 				if err != nil {
-					vm.ArgError(index, err.Error())
-					break
+					return fail(
+						fmt.Errorf("argument #%d %s", index+1, err))
 				}
 
 				i = append(i, value)
 			}
 
-			if err != nil {
-				results[ret] = R.ValueOf((*error)(&err)).Elem()
-				return
-			}
+			top := vm.GetTop()
 
 			err = vm.CallByParam(
 				lua.P{
@@ -353,16 +448,18 @@ func makeFunc(vm *VM, v R.Value, src, self Value) R.Value {
 				}, i...)
 
 			if err != nil {
-				results[ret] = R.ValueOf((*error)(&err)).Elem()
-				return
+				vm.SetTop(top)
+				return fail(err)
 			}
 
+			defer vm.SetTop(top)
+
 			for index := 0; index < ret; index++ {
 
 				var (
 					ot = t.Out(index)
 					ov = R.New(ot)
-					lv = vm.Get(-1 * (ret - index))
+					lv = vm.Get(top + index + 1)
 				)
 
 				if lv.Type() == lua.LTTable {
@@ -371,16 +468,14 @@ func makeFunc(vm *VM, v R.Value, src, self Value) R.Value {
 					opts.base = Nil
 				}
 
-				if !goValue(vm, ov.Elem(), lv, opts) {
-					results[ret] = R.ValueOf(
+				if !(goValue(vm, ov.Elem(), lv, opts) && opts.Ok()) {
+					return fail(
 						opts.GetError(
 							errTypeConvert{
-								fmt.Sprintf("%s(Argument #%d)", t.Name(), index),
-								src.Type(),
+								fmt.Sprintf("%s(Return #%d)", t.Name(), index+1),
+								lv.Type(),
 								ot,
 							}))
-
-					return
 				}
 
 				results[index] = ov.Elem()
@@ -395,7 +490,6 @@ func goValueFunction(vm *VM, v R.Value, src Value, opts *asOptions) (ok bool) {
 
 	ok = true
 	var (
-		t  = v.Type()
 		lt = src.Type()
 		k  = v.Kind()
 	)
@@ -404,11 +498,15 @@ func goValueFunction(vm *VM, v R.Value, src Value, opts *asOptions) (ok bool) {
 		return false
 	}
 
-	if t.NumOut() == 0 || t.Out(t.NumOut()-1) != typeError {
-		return opts.NewError("bind lua function, the go function last out argument must bu error")
+	var (
+		self = Nil
+	)
+
+	if opts.method {
+		self = opts.base
 	}
 
-	v.Set(makeFunc(vm, v, src, opts.base))
+	v.Set(makeFunc(vm, v, src, self))
 
 	return
 
@@ -428,27 +526,35 @@ func goValueObject(vm *VM, v R.Value, src Value, opts *asOptions) (ok bool) {
 		return false
 	}
 
-	n := v.NumField()
+	if !opts.visit(src) {
+		return
+	}
+
+	defer opts.tracker.release(src)
 
-	for index := 0; index < n; index++ {
+	for _, fp := range planOf(t).fields {
 
 		var (
-			vf  = v.Field(index)
-			tf  = t.Field(index)
-			tag = makeTags(tf)
+			vf  = v.Field(fp.index)
+			tag = fp.tag
 		)
 
 		opts.optional = tag.option
 		opts.field = tag.name
 		opts.base = src
+		opts.method = tag.method
 
-		if tag.skip {
+		if tag.skip || fp.class || !fp.exported {
 			continue
 		}
 
 		lf := vm.GetField(src, tag.name)
 
-		if !(goValue(vm, vf, lf, opts) && opts.Ok()) {
+		if !goValue(vm, vf, lf, opts) && opts.Ok() {
+			opts.Error(errTypeConvert{tag.name, lf.Type(), fp.t})
+		}
+
+		if !opts.Ok() {
 			return
 		}
 
@@ -458,6 +564,33 @@ func goValueObject(vm *VM, v R.Value, src Value, opts *asOptions) (ok bool) {
 
 }
 
+func goValueChannel(vm *VM, v R.Value, src Value, opts *asOptions) (ok bool) {
+
+	ch, is := src.(lua.LChannel)
+
+	if !is || v.Kind() != R.Chan {
+		return false
+	}
+
+	var (
+		t = v.Type()
+	)
+
+	if typeChannel.ConvertibleTo(t) {
+		v.Set(R.ValueOf(ch).Convert(t))
+	} else {
+		x, err := goChannel(vm, ch, t)
+
+		if err != nil {
+			return opts.Error(err)
+		}
+
+		v.Set(x)
+	}
+
+	return true
+}
+
 func goValueBuiltin(vm *VM, v R.Value, src Value, opts *asOptions) (ok bool) {
 
 	if !v.CanInterface() {
@@ -511,21 +644,35 @@ func goValue(vm *VM, v R.Value, src Value, opts *asOptions) bool {
 	}()
 
 	var (
-		ok = true
-		lt = src.Type()
+		ok      = true
+		lt      = src.Type()
+		tracker = opts.track()
 	)
 
+	defer tracker.leave()
+
+	if !tracker.enter() {
+		opts.Error(&errorDepth{tracker.options.MaxDepth})
+		return true
+	}
+
+	if x, found := tracker.reuse(src, v.Type()); found {
+		v.Set(x)
+		return true
+	}
+
 	switch lt {
 	case lua.LTFunction:
 		if opts.skipFunc {
 			return false
 		}
-	case lua.LTChannel, lua.LTThread:
+	case lua.LTThread:
 		return false
 	}
 
 	switch {
 	case goValueBuiltin(vm, v, src, opts):
+	case goValueChannel(vm, v, src, opts):
 	case goValuePod(vm, v, src, opts):
 	case goValueFunction(vm, v, src, opts):
 	case goValueObject(vm, v, src, opts):
@@ -538,6 +685,10 @@ func goValue(vm *VM, v R.Value, src Value, opts *asOptions) bool {
 }
 
 func As(vm *VM, src Value, value interface{}) error {
+	return AsWith(vm, src, value, DefaultDecodeOptions)
+}
+
+func AsWith(vm *VM, src Value, value interface{}, options DecodeOptions) error {
 
 	var (
 		v    = R.ValueOf(value)
@@ -548,6 +699,7 @@ func As(vm *VM, src Value, value interface{}) error {
 			base:     Nil,
 			optional: false,
 			raise:    true,
+			tracker:  newTracker(options),
 		}
 	)
 
@@ -567,3 +719,44 @@ func As(vm *VM, src Value, value interface{}) error {
 		"", src.Type(), v.Type(),
 	}
 }
+
+// BindMethod decodes the function name of the lua object obj into the go
+// func pointed by fn, obj is passed as self on every call like obj:name(...).
+func BindMethod(vm *VM, obj Value, name string, fn interface{}) error {
+
+	var (
+		v    = R.ValueOf(fn)
+		opts = &asOptions{
+			vm:       vm,
+			field:    name,
+			skipFunc: false,
+			base:     obj,
+			optional: false,
+			raise:    false,
+			method:   true,
+			tracker:  newTracker(DefaultDecodeOptions),
+		}
+	)
+
+	if v.Kind() != R.Ptr || v.Elem().Kind() != R.Func {
+		return errors.New("bind method must give a func pointer")
+	}
+
+	if t := obj.Type(); t != lua.LTTable && t != lua.LTUserData {
+		return errTypeConvert{name, t, v.Elem().Type()}
+	}
+
+	var (
+		lv = vm.GetField(obj, name)
+	)
+
+	if lv.Type() != lua.LTFunction {
+		return errTypeConvert{name, lv.Type(), v.Elem().Type()}
+	}
+
+	if !(goValueFunction(vm, v.Elem(), lv, opts) && opts.Ok()) {
+		return opts.GetError(errTypeConvert{name, lv.Type(), v.Elem().Type()})
+	}
+
+	return nil
+}
@@ -0,0 +1,398 @@
+package lua
+
+import (
+	"fmt"
+	R "reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var (
+	registry = struct {
+		sync.RWMutex
+		loaders map[string]ModuleLoader
+	}{
+		loaders: make(map[string]ModuleLoader),
+	}
+)
+
+// RegisterModule makes loader available under name to LoadRegistered, it is
+// meant to be called from the init function of a library package.
+func RegisterModule(name string, loader ModuleLoader) {
+
+	registry.Lock()
+	defer registry.Unlock()
+
+	if loader == nil {
+		panic("register module " + name + " with a nil loader")
+	}
+
+	if _, ok := registry.loaders[name]; ok {
+		panic("module " + name + " registered twice")
+	}
+
+	registry.loaders[name] = loader
+}
+
+func RegisteredModules() []string {
+
+	registry.RLock()
+	defer registry.RUnlock()
+
+	var (
+		names = make([]string, 0, len(registry.loaders))
+	)
+
+	for name := range registry.loaders {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// LoadRegistered loads the registered modules given by names, all of them if
+// no name is given.
+func LoadRegistered(vm *VM, names ...string) error {
+
+	if len(names) == 0 {
+		names = RegisteredModules()
+	}
+
+	for _, name := range names {
+
+		registry.RLock()
+		loader, ok := registry.loaders[name]
+		registry.RUnlock()
+
+		if !ok {
+			return fmt.Errorf("module %s is not registered", name)
+		}
+
+		if err := LoadModuleWith(vm, loader, LoadOptions{Alias: name}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type ParamInfo struct {
+	Type     R.Type
+	Optional bool
+	Variadic bool
+}
+
+type FunctionInfo struct {
+	Name      string
+	Signature string
+	Doc       string
+	Params    []ParamInfo
+	Results   []R.Type
+}
+
+type FieldInfo struct {
+	Name string
+	Type R.Type
+	Doc  string
+}
+
+type TypeInfo struct {
+	Name    string
+	UUID    string
+	Doc     string
+	Type    R.Type
+	Fields  []FieldInfo
+	Methods []FunctionInfo
+}
+
+type ModuleInfo struct {
+	Name      string
+	Doc       string
+	Functions []FunctionInfo
+	Values    []FieldInfo
+	Types     []TypeInfo
+	Modules   []ModuleInfo
+}
+
+// Info describes a bound invoker, it is only complete once VMGFunction has
+// been called with it.
+func (m *Invoker) Info() FunctionInfo {
+
+	var (
+		info = FunctionInfo{
+			Name:      m.Name,
+			Signature: m.Signature(),
+			Doc:       m.Doc,
+			Params:    make([]ParamInfo, 0),
+			Results:   make([]R.Type, 0),
+		}
+		fixed = len(m.defaults)
+	)
+
+	if m.caller {
+		return info
+	}
+
+	for index, it := range m.params {
+		info.Params = append(info.Params, ParamInfo{
+			Type:     it,
+			Optional: index >= m.required,
+			Variadic: index >= fixed,
+		})
+	}
+
+	for index := 0; index < m.ret; index++ {
+		info.Results = append(info.Results, m.ft.Out(index))
+	}
+
+	return info
+}
+
+func functionInfos(value interface{}, docs map[string]string, cb func(i *Invoker)) []FunctionInfo {
+
+	var (
+		invokers = make([]*Invoker, 0)
+		infos    = make([]FunctionInfo, 0)
+	)
+
+	memberFunctions(value, func(v R.Value, index int, i *Invoker) {
+		i.Doc = docs[i.Name]
+		i.Caller = func(vm *VM) (R.Value, error) {
+			return R.Value{}, nil
+		}
+
+		if cb != nil {
+			cb(i)
+		}
+
+		invokers = append(invokers, i)
+	})
+
+	for _, i := range invokers {
+		infos = append(infos, i.Info())
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Name < infos[j].Name
+	})
+
+	return infos
+}
+
+func (m *Type) Info() TypeInfo {
+
+	var (
+		info = TypeInfo{
+			Name:   m.Name,
+			UUID:   m.UUID,
+			Doc:    m.Doc,
+			Type:   m.Type,
+			Fields: make([]FieldInfo, 0),
+		}
+		ot = m.Type
+	)
+
+	if ot.Kind() == R.Ptr {
+		ot = ot.Elem()
+	}
+
+	for index := 0; index < ot.NumField(); index++ {
+
+		var (
+			ft = ot.Field(index)
+		)
+
+		if ft.PkgPath != "" || ft.Anonymous {
+			continue
+		}
+
+		info.Fields = append(info.Fields, FieldInfo{ft.Name, ft.Type, m.Docs[ft.Name]})
+	}
+
+	info.Methods = functionInfos(m.Type, m.Docs, func(i *Invoker) {
+		i.Self = true
+	})
+
+	return info
+}
+
+func (m *Module) Info() ModuleInfo {
+
+	var (
+		info = ModuleInfo{
+			Name:      m.Name,
+			Doc:       m.Doc,
+			Functions: make([]FunctionInfo, 0),
+			Values:    make([]FieldInfo, 0),
+			Types:     make([]TypeInfo, 0),
+			Modules:   make([]ModuleInfo, 0),
+		}
+	)
+
+	if m.Members != nil {
+		info.Functions = functionInfos(m.Members, m.Docs, nil)
+	}
+
+	for name, x := range m.values {
+		info.Values = append(info.Values, FieldInfo{name, R.TypeOf(x), m.Docs[name]})
+	}
+
+	for name := range m.lazies {
+		info.Values = append(info.Values, FieldInfo{name, nil, m.Docs[name]})
+	}
+
+	sort.Slice(info.Values, func(i, j int) bool {
+		return info.Values[i].Name < info.Values[j].Name
+	})
+
+	for _, x := range m.types {
+		info.Types = append(info.Types, x.Info())
+	}
+
+	for _, child := range m.children {
+		info.Modules = append(info.Modules, child.Info())
+	}
+
+	return info
+}
+
+// RegisteredInfos describes the modules of the global registry, under the
+// name they are registered with. Each loader is called once, the module it
+// returns is closed once described.
+func RegisteredInfos() []ModuleInfo {
+
+	var (
+		infos = make([]ModuleInfo, 0)
+	)
+
+	for _, name := range RegisteredModules() {
+
+		registry.RLock()
+		loader := registry.loaders[name]
+		registry.RUnlock()
+
+		m := loader()
+		info := m.Info()
+		info.Name = name
+		infos = append(infos, info)
+
+		m.release()
+	}
+
+	return infos
+}
+
+// LoadedModules describes the modules which can be required from vm, sorted
+// by the name they are registered under.
+func LoadedModules(vm *VM) []ModuleInfo {
+
+	var (
+		state = loadState(vm)
+		names = make([]string, 0, len(state.modules))
+		infos = make([]ModuleInfo, 0, len(state.modules))
+	)
+
+	for name := range state.modules {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		info := state.modules[name].Info()
+		info.Name = name
+		infos = append(infos, info)
+	}
+
+	return infos
+}
+
+func (m ModuleInfo) String() string {
+
+	var (
+		b = &strings.Builder{}
+	)
+
+	fmt.Fprintf(b, "module %s", m.Name)
+
+	for _, fn := range m.Functions {
+		fmt.Fprintf(b, "\n  %s.%s", m.Name, fn.Signature)
+	}
+
+	for _, value := range m.Values {
+		fmt.Fprintf(b, "\n  %s.%s", m.Name, value.Name)
+	}
+
+	for _, child := range m.Modules {
+		fmt.Fprintf(b, "\n  %s.%s", m.Name, child.Name)
+	}
+
+	for _, x := range m.Types {
+		fmt.Fprintf(b, "\n  type %s", x.Name)
+
+		for _, field := range x.Fields {
+			fmt.Fprintf(b, "\n    %s %s", field.Name, field.Type)
+		}
+
+		for _, method := range x.Methods {
+			fmt.Fprintf(b, "\n    %s:%s", x.Name, method.Signature)
+		}
+	}
+
+	return b.String()
+}
+
+// LoadIntrospection installs the globals modules and help, modules.list()
+// returns the names of the modules which can be required, help(x) describes
+// a go function or a module given by its name.
+func LoadIntrospection(vm *VM) {
+
+	var (
+		modules = vm.NewTable()
+	)
+
+	vm.SetField(modules, "list", vm.NewFunction(func(vm *VM) int {
+
+		var (
+			tbl = vm.NewTable()
+		)
+
+		for _, info := range LoadedModules(vm) {
+			tbl.Append(String(info.Name))
+		}
+
+		vm.Push(tbl)
+		return 1
+	}))
+
+	vm.SetGlobal("modules", modules)
+
+	vm.SetGlobal("help", vm.NewFunction(func(vm *VM) int {
+
+		var (
+			value = vm.Get(1)
+			state = loadState(vm)
+		)
+
+		switch x := value.(type) {
+		case *Function:
+			if signature, ok := state.signatures[x]; ok {
+				vm.Push(String(signature))
+				return 1
+			}
+		case String:
+			if m, ok := state.modules[string(x)]; ok {
+				info := m.Info()
+				info.Name = string(x)
+				vm.Push(String(info.String()))
+				return 1
+			}
+		}
+
+		vm.Push(Nil)
+		return 1
+	}))
+}
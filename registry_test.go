@@ -0,0 +1,110 @@
+package lua
+
+import (
+	"strings"
+	"testing"
+)
+
+type registryMembers struct {
+	ModuleMembers
+	closed *int
+}
+
+func (m *registryMembers) Add(a int, b int) int {
+	return a + b
+}
+
+func (m *registryMembers) Close() error {
+	*m.closed++
+	return nil
+}
+
+var (
+	registryClosed int
+)
+
+func init() {
+	RegisterModule("registry_test", func() *Module {
+		return &Module{
+			Name:    "ignored",
+			Docs:    map[string]string{"Add": "adds two numbers"},
+			Members: &registryMembers{closed: &registryClosed},
+		}
+	})
+}
+
+func TestRegistry(t *testing.T) {
+
+	var (
+		vm = New()
+	)
+
+	registryClosed = 0
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("registering a name twice should panic")
+			}
+		}()
+
+		RegisterModule("registry_test", func() *Module { return &Module{} })
+	}()
+
+	var (
+		found bool
+	)
+
+	for _, info := range RegisteredInfos() {
+		if info.Name == "registry_test" {
+			found = len(info.Functions) == 1 && info.Functions[0].Doc == "adds two numbers"
+		}
+	}
+
+	if !found || registryClosed != 1 {
+		t.Fatalf("registered infos found %v, closed %d members", found, registryClosed)
+	}
+
+	if err := LoadRegistered(vm, "missing"); err == nil {
+		t.Fatal("loading a module which is not registered should fail")
+	}
+
+	if err := LoadRegistered(vm, "registry_test"); err != nil {
+		t.Fatal(err)
+	}
+
+	LoadIntrospection(vm)
+
+	err := vm.DoString(`
+		local m = require("registry_test")
+		assert(m.Add(1, 2) == 3)
+		local names = table.concat(modules.list(), ",")
+		assert(names == "registry_test", names)
+		assert(help(m.Add) == "registry_test.Add(int, int) int", help(m.Add))
+		assert(string.find(help("registry_test"), "module registry_test", 1, true))
+		assert(help("missing") == nil and help(print) == nil)
+	`)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Close(vm); err != nil {
+		t.Fatal(err)
+	}
+
+	if registryClosed != 2 {
+		t.Fatalf("%d members closed", registryClosed)
+	}
+
+	other := New()
+	defer Close(other)
+
+	if infos := LoadedModules(other); len(infos) != 0 {
+		t.Fatalf("a new vm has modules %v", infos)
+	}
+
+	if !strings.Contains(RegisteredInfos()[0].String(), "module ") {
+		t.Fatal("module info should describe the module")
+	}
+}
@@ -0,0 +1,167 @@
+package lua
+
+import (
+	"io"
+	"runtime"
+	"sort"
+	"sync"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+var (
+	// objects holds the typed objects needing a cleanup, shared by all the
+	// vms and keyed by the identity of the object.
+	objects = struct {
+		sync.Mutex
+		entries map[reference]*resource
+	}{}
+)
+
+// resource is a typed object to close or finalize, users counts its userdata
+// alive in all the vms, the last one to go releases the object.
+type resource struct {
+	ref   reference
+	value interface{}
+	users int
+}
+
+func acquire(ref reference, value interface{}) *resource {
+
+	objects.Lock()
+	defer objects.Unlock()
+
+	if objects.entries == nil {
+		objects.entries = make(map[reference]*resource)
+	}
+
+	r, ok := objects.entries[ref]
+
+	if !ok {
+		r = &resource{ref: ref, value: value}
+		objects.entries[ref] = r
+	}
+
+	r.users++
+
+	return r
+}
+
+// release drops a user of the object, the last one finalizes the object and
+// also closes it if its vm is closing.
+func (m *resource) release(closing bool) (err error) {
+
+	objects.Lock()
+
+	m.users--
+	last := m.users == 0
+
+	if last {
+		delete(objects.entries, m.ref)
+	}
+
+	objects.Unlock()
+
+	if !last {
+		return nil
+	}
+
+	if closer, ok := m.value.(io.Closer); ok && closing {
+		err = closer.Close()
+	}
+
+	if x, ok := m.value.(Finalizer); ok {
+		x.Finalize()
+	}
+
+	return err
+}
+
+// resources holds the userdata of a vm whose objects need a cleanup, it
+// holds the objects but not their userdata, so the userdata can still be
+// collected.
+type resources struct {
+	sync.Mutex
+	next    uint64
+	entries map[uint64]*resource
+}
+
+// track counts ud as a user of its object, objects without an identity are
+// not tracked.
+func (m *resources) track(ud *lua.LUserData, ref reference, identity bool) {
+
+	var (
+		_, closable    = ud.Value.(io.Closer)
+		_, finalizable = ud.Value.(Finalizer)
+	)
+
+	if !identity || !closable && !finalizable {
+		return
+	}
+
+	m.Lock()
+
+	if m.entries == nil {
+		m.entries = make(map[uint64]*resource)
+	}
+
+	id := m.next
+	m.next++
+	m.entries[id] = acquire(ref, ud.Value)
+
+	m.Unlock()
+
+	runtime.AddCleanup(ud, m.collect, id)
+}
+
+// collect runs once the userdata of id is collected.
+func (m *resources) collect(id uint64) {
+
+	m.Lock()
+	r, ok := m.entries[id]
+	delete(m.entries, id)
+	m.Unlock()
+
+	if ok {
+		r.release(false)
+	}
+}
+
+func (m *resources) count() int {
+
+	m.Lock()
+	defer m.Unlock()
+
+	return len(m.entries)
+}
+
+// release drops the userdata still alive, the most recent first, closing
+// and finalizing the objects no other vm uses. The first error of a Close
+// method is returned.
+func (m *resources) release() (err error) {
+
+	m.Lock()
+	entries := m.entries
+	m.entries = nil
+	m.Unlock()
+
+	var (
+		ids = make([]uint64, 0, len(entries))
+	)
+
+	for id := range entries {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] > ids[j]
+	})
+
+	for _, id := range ids {
+		if e := entries[id].release(true); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	return err
+}
@@ -0,0 +1,289 @@
+package lua
+
+import (
+	stdlog "log"
+	"os"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type resourceLog struct {
+	sync.Mutex
+	events []string
+}
+
+func (m *resourceLog) add(event string) {
+
+	m.Lock()
+	defer m.Unlock()
+
+	m.events = append(m.events, event)
+}
+
+func (m *resourceLog) count(event string) (n int) {
+
+	m.Lock()
+	defer m.Unlock()
+
+	for _, x := range m.events {
+		if x == event {
+			n++
+		}
+	}
+
+	return n
+}
+
+type resourceObject struct {
+	Typed
+	Name string
+	log  *resourceLog
+}
+
+func (m *resourceObject) Close() error {
+	m.log.add("close " + m.Name)
+	return nil
+}
+
+func (m *resourceObject) Finalize() {
+	m.log.add("finalize " + m.Name)
+}
+
+type resourceMembers struct {
+	ModuleMembers
+	log *resourceLog
+}
+
+func (m *resourceMembers) Close() error {
+	m.log.add("close module")
+	return nil
+}
+
+var (
+	resourceType = &Type{
+		UUID: "5d2e8b6c-3f4a-4c1e-9b7d-2a6f0e8c4d13",
+		Name: "Resource",
+		Type: GoType((*resourceObject)(nil)),
+	}
+)
+
+func TestCloseOrder(t *testing.T) {
+
+	var (
+		vm  = New()
+		log = &resourceLog{}
+	)
+
+	Define(vm, resourceType)
+
+	LoadModule(vm, func() *Module {
+		return &Module{Name: "resources", Members: &resourceMembers{log: log}}
+	})
+
+	OnClose(vm, func() {
+		log.add("hook")
+	})
+
+	for _, name := range []string{"a", "b"} {
+
+		value, err := NewEncoder(vm, 0).Encode(&resourceObject{Name: name, log: log})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		vm.SetGlobal(name, value)
+	}
+
+	if err := Close(vm); err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		want = []string{"close b", "finalize b", "close a", "finalize a", "hook", "close module"}
+	)
+
+	if len(log.events) != len(want) {
+		t.Fatalf("close ran %v", log.events)
+	}
+
+	for index, event := range want {
+		if log.events[index] != event {
+			t.Fatalf("close ran %v", log.events)
+		}
+	}
+}
+
+func TestFinalizeOnCollect(t *testing.T) {
+
+	var (
+		vm    = New()
+		log   = &resourceLog{}
+		state = loadState(vm)
+	)
+
+	defer Close(vm)
+
+	Define(vm, resourceType)
+
+	func() {
+		for index := 0; index < 100; index++ {
+			if _, err := NewEncoder(vm, 0).Encode(&resourceObject{Name: "x", log: log}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}()
+
+	for index := 0; index < 100 && log.count("finalize x") < 100; index++ {
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+
+	if n := log.count("finalize x"); n != 100 {
+		t.Fatalf("%d of 100 dropped objects finalized", n)
+	}
+
+	if n := log.count("close x"); n != 0 {
+		t.Fatalf("%d dropped objects closed", n)
+	}
+
+	state.resources.Lock()
+	defer state.resources.Unlock()
+
+	if n := len(state.resources.entries); n != 0 {
+		t.Fatalf("%d resources left", n)
+	}
+}
+
+func TestReleaseLeaked(t *testing.T) {
+
+	var (
+		log    = &resourceLog{}
+		output = &strings.Builder{}
+	)
+
+	stdlog.SetOutput(output)
+	defer stdlog.SetOutput(os.Stderr)
+
+	func() {
+
+		vm := New()
+		Define(vm, resourceType)
+
+		value, err := NewEncoder(vm, 0).Encode(&resourceObject{Name: "x", log: log})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		vm.SetGlobal("x", value)
+
+		OnClose(vm, func() {
+			log.add("hook")
+		})
+
+		vm.Close()
+	}()
+
+	for index := 0; index < 100 && log.count("hook") == 0; index++ {
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+
+	if log.count("finalize x") != 1 || log.count("hook") != 1 {
+		t.Fatalf("leaked vm released %v", log.events)
+	}
+
+	if !strings.Contains(output.String(), "without lua.Close") {
+		t.Fatalf("leaked vm not reported: %q", output.String())
+	}
+}
+
+type resourceEmpty struct {
+	Typed
+}
+
+var (
+	resourceEmptyLog = &resourceLog{}
+)
+
+func (m *resourceEmpty) Close() error {
+	resourceEmptyLog.add("close empty")
+	return nil
+}
+
+func TestReleaseShared(t *testing.T) {
+
+	var (
+		a, b = New(), New()
+		log  = &resourceLog{}
+		obj  = &resourceObject{Name: "shared", log: log}
+	)
+
+	for _, vm := range []*VM{a, b} {
+
+		Define(vm, resourceType)
+
+		value, err := NewEncoder(vm, 0).Encode(obj)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		vm.SetGlobal("obj", value)
+	}
+
+	if err := Close(a); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(log.events) != 0 {
+		t.Fatalf("object released while b uses it: %v", log.events)
+	}
+
+	if err := b.DoString(`assert(obj.Name == "shared")`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Close(b); err != nil {
+		t.Fatal(err)
+	}
+
+	if log.count("close shared") != 1 || log.count("finalize shared") != 1 {
+		t.Fatalf("shared object released %v", log.events)
+	}
+}
+
+func TestReleaseZeroSize(t *testing.T) {
+
+	var (
+		vm = New()
+	)
+
+	Define(vm, &Type{
+		UUID: "0b7e4c2a-6d1f-4a8e-9c3b-5e2d8f1a7c46",
+		Name: "Empty",
+		Type: GoType((*resourceEmpty)(nil)),
+	})
+
+	for index := 0; index < 3; index++ {
+		if _, err := NewEncoder(vm, 0).Encode(&resourceEmpty{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n := loadState(vm).resources.count(); n != 0 {
+		t.Fatalf("%d zero sized objects tracked", n)
+	}
+
+	if err := Close(vm); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := resourceEmptyLog.count("close empty"); n != 0 {
+		t.Fatalf("zero sized objects closed %d times", n)
+	}
+}
@@ -0,0 +1,156 @@
+package lua
+
+import (
+	"log"
+	R "reflect"
+	"runtime"
+
+	lua "github.com/yuin/gopher-lua"
+)
+
+const (
+	// stateName is the registry key of the go state.
+	stateName = "mz-eco/lua.state"
+)
+
+type goState struct {
+	panicHook    PanicHook
+	callbackHook CallbackHook
+	modules      map[string]*Module
+	signatures   map[*Function]string
+	*lifecycle
+}
+
+// lifecycle is what Close releases, it is kept apart from the state, whose
+// functions reach the vm, so it can be released once a leaked vm is
+// collected.
+type lifecycle struct {
+	bridges    map[reference]R.Value
+	stop       chan struct{}
+	closers    []func() error
+	resources  resources
+	identities identities
+	closed     bool
+}
+
+func (m *goState) describe(fn Value, signature string) {
+
+	x, ok := fn.(*Function)
+
+	if !ok {
+		return
+	}
+
+	if m.signatures == nil {
+		m.signatures = make(map[*Function]string)
+	}
+
+	m.signatures[x] = signature
+}
+
+// OnClose registers fn to be called by Close, hooks run in the reverse order
+// they were registered.
+func OnClose(vm *VM, fn func()) {
+
+	onClose(vm, func() error {
+		fn()
+		return nil
+	})
+}
+
+func onClose(vm *VM, fn func() error) {
+
+	state := loadState(vm)
+	state.closers = append(state.closers, fn)
+}
+
+// Close closes the typed objects implementing io.Closer and finalizes the
+// ones implementing Finalizer which are still alive, runs the close hooks of
+// vm, which close the modules members, then closes vm. The first error
+// returned by a Close method is returned.
+//
+// A vm closed with vm.Close is reported when it is collected, its objects
+// and hooks are released then, from the cleanup goroutine.
+func Close(vm *VM) (err error) {
+
+	err = loadState(vm).release()
+
+	vm.Close()
+
+	return err
+}
+
+func (m *lifecycle) release() (err error) {
+
+	m.closed = true
+
+	err = m.resources.release()
+
+	for index := len(m.closers) - 1; index >= 0; index-- {
+		if e := m.closers[index](); e != nil && err == nil {
+			err = e
+		}
+	}
+
+	m.closers = nil
+	m.identities.clear()
+
+	if m.stop != nil {
+		close(m.stop)
+		m.stop = nil
+		m.bridges = nil
+	}
+
+	return err
+}
+
+// leaked runs when a vm is collected, it releases what Close did not.
+func (m *lifecycle) leaked() {
+
+	if m.closed {
+		return
+	}
+
+	log.Printf("lua: vm collected without lua.Close, releasing %d objects and %d close hooks", m.resources.count(), len(m.closers))
+
+	if err := m.release(); err != nil {
+		log.Printf("lua: release leaked vm: %s", err)
+	}
+}
+
+// loadState returns the go state of vm, it is kept in the registry, out of
+// reach of scripts, and shared by the threads of vm.
+func loadState(vm *VM) (state *goState) {
+
+	var (
+		registry = vm.G.Registry
+	)
+
+	lv := registry.RawGetString(stateName)
+
+	if lv.Type() == lua.LTNil {
+		state = &goState{lifecycle: &lifecycle{}}
+
+		runtime.AddCleanup(vm, (*lifecycle).leaked, state.lifecycle)
+
+		ud := vm.NewUserData()
+		ud.Value = state
+		registry.RawSetString(stateName, ud)
+
+		return
+	} else {
+		ud, ok := lv.(*lua.LUserData)
+
+		if !ok {
+			panic("lua go state is not a user data")
+		}
+
+		state, ok = ud.Value.(*goState)
+
+		if !ok {
+			panic("lua go state value must *goState")
+		}
+
+		return
+	}
+}
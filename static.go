@@ -0,0 +1,101 @@
+package lua
+
+import (
+	R "reflect"
+)
+
+// StaticFunction is a go function bound to lua without reflection, the
+// wrappers are generated by cmd/luagen.
+type StaticFunction func(c *Call) int
+
+// StaticMethod is the generated wrapper of a method of a Typed type, self is
+// the go value behind the userdata.
+type StaticMethod func(self interface{}, c *Call) int
+
+// StaticMembers is implemented by module members with generated wrappers,
+// they replace the reflective binding of the methods of the same name.
+type StaticMembers interface {
+	LuaFunctions() map[string]StaticFunction
+}
+
+// StaticMethods is implemented by Typed types with generated wrappers.
+type StaticMethods interface {
+	LuaMethods() map[string]StaticMethod
+}
+
+// StaticSignatures is implemented by the types with generated wrappers, it
+// gives the signatures help shows, as Invoker.Signature writes them.
+type StaticSignatures interface {
+	LuaSignatures() map[string]string
+}
+
+var (
+	typeStaticMembers    = R.TypeOf((*StaticMembers)(nil)).Elem()
+	typeStaticMethods    = R.TypeOf((*StaticMethods)(nil)).Elem()
+	typeStaticSignatures = R.TypeOf((*StaticSignatures)(nil)).Elem()
+)
+
+func staticFunction(name string, convention ReturnConvention, protected bool, self bool, fn StaticFunction) GFunction {
+
+	var (
+		base = 1
+	)
+
+	if self {
+		base = 2
+	}
+
+	return func(vm *VM) int {
+		return protect(vm, name, func() int {
+
+			var (
+				c = newCall(vm, name, base)
+			)
+
+			c.convention = convention
+			c.protect = protected
+
+			return fn(c)
+		})
+	}
+}
+
+// isReserved tells if the method name of t is used by the binding itself,
+// like the entry point of generated wrappers, rather than a member to bind.
+func isReserved(t R.Type, name string) bool {
+
+	switch name {
+	case "LuaFunctions":
+		return t.Implements(typeStaticMembers)
+	case "LuaMethods":
+		return t.Implements(typeStaticMethods)
+	case "LuaSignatures":
+		return t.Implements(typeStaticSignatures)
+	case "Close":
+		return t.Implements(typeModuleMembers) && t.Implements(typeCloser)
+	}
+
+	return false
+}
+
+// Fail reports err according to the return convention of the function, ret
+// is the number of values the function returns besides the error.
+func (m *Call) Fail(ret int, err error) int {
+	return fail(m.vm, m.convention, m.protect, ret, err)
+}
+
+// signatures returns the generated signatures of x, prefixed by owner.
+func signatures(x interface{}, owner string) map[string]string {
+
+	var (
+		described = make(map[string]string)
+	)
+
+	if static, ok := x.(StaticSignatures); ok {
+		for name, signature := range static.LuaSignatures() {
+			described[name] = owner + signature
+		}
+	}
+
+	return described
+}
@@ -10,6 +10,7 @@ type tags struct {
 	option bool
 	name   string
 	skip   bool
+	method bool
 }
 
 func makeTags(field R.StructField) tags {
@@ -36,6 +37,8 @@ func makeTags(field R.StructField) tags {
 			x.skip = true
 		case "option":
 			x.option = true
+		case "method":
+			x.method = true
 		default:
 			panic(
 				fmt.Sprintf("unknonw tag %s for field %s", kv[0], field.Name))
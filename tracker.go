@@ -0,0 +1,134 @@
+package lua
+
+import (
+	R "reflect"
+)
+
+type CyclePolicy int
+
+const (
+	CycleError CyclePolicy = iota
+	CycleReuse
+)
+
+type SequencePolicy int
+
+const (
+	SequenceIgnore SequencePolicy = iota
+	SequenceReject
+)
+
+type DecodeOptions struct {
+	MaxDepth int
+	Cycle    CyclePolicy
+	Sequence SequencePolicy
+}
+
+var (
+	DefaultDecodeOptions = DecodeOptions{
+		MaxDepth: DefaultMaxDepth,
+		Cycle:    CycleError,
+		Sequence: SequenceIgnore,
+	}
+)
+
+// sequence returns the length of the array part of tbl, explicit nil holes
+// included, and the first key which is not part of it (Nil if there is none).
+func sequence(tbl *Table) (n int, key Value) {
+
+	n = tbl.MaxN()
+	key = Nil
+
+	tbl.ForEach(func(k Value, _ Value) {
+
+		if key != Nil {
+			return
+		}
+
+		if x, ok := k.(Number); ok {
+			if i := int(x); Number(i) == x && i >= 1 && i <= n {
+				return
+			}
+		}
+
+		key = k
+	})
+
+	return n, key
+}
+
+type decoded struct {
+	tbl *Table
+	t   R.Type
+}
+
+// tracker follows the tables being decoded, so a table which references
+// itself is reported (or reused) instead of recursing forever.
+type tracker struct {
+	options  DecodeOptions
+	depth    int
+	visiting map[*Table]bool
+	values   map[decoded]R.Value
+}
+
+func newTracker(options DecodeOptions) *tracker {
+	return &tracker{
+		options:  options,
+		depth:    0,
+		visiting: make(map[*Table]bool),
+		values:   make(map[decoded]R.Value),
+	}
+}
+
+func (m *tracker) enter() bool {
+	m.depth++
+	return m.options.MaxDepth <= 0 || m.depth <= m.options.MaxDepth
+}
+
+func (m *tracker) leave() {
+	m.depth--
+}
+
+func (m *tracker) visit(src Value) bool {
+
+	tbl, ok := src.(*Table)
+
+	if !ok {
+		return true
+	}
+
+	if m.visiting[tbl] {
+		return false
+	}
+
+	m.visiting[tbl] = true
+
+	return true
+}
+
+func (m *tracker) release(src Value) {
+
+	if tbl, ok := src.(*Table); ok {
+		delete(m.visiting, tbl)
+	}
+}
+
+func (m *tracker) reuse(src Value, t R.Type) (R.Value, bool) {
+
+	tbl, ok := src.(*Table)
+
+	if !ok || m.options.Cycle != CycleReuse {
+		return R.Value{}, false
+	}
+
+	v, ok := m.values[decoded{tbl, t}]
+
+	return v, ok
+}
+
+func (m *tracker) remember(src Value, v R.Value) {
+
+	if tbl, ok := src.(*Table); ok {
+		m.values[decoded{tbl, v.Type()}] = v
+	}
+}
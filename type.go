@@ -11,10 +11,13 @@ import (
 )
 
 type Type struct {
-	UUID string
-	Name string
-	Type R.Type
-	name string
+	UUID    string
+	Name    string
+	Doc     string
+	Docs    map[string]string
+	Type    R.Type
+	Reflect bool
+	name    string
 }
 
 func (m *Type) checkValue(vm *VM) (R.Value, error) {
@@ -53,6 +56,7 @@ type TypeLoader func() *Type
 func setter(vm *VM, x *Type) GFunction {
 	i := &Invoker{
 		Name: "__newindex",
+		Self: true,
 		GoFunc: func(c *Call) int {
 
 			var (
@@ -60,7 +64,7 @@ func setter(vm *VM, x *Type) GFunction {
 			)
 
 			if err != nil {
-				c.ArgError(1, err.Error())
+				c.ArgError(1, "%s", err)
 				return 0
 			}
 
@@ -116,32 +120,75 @@ func setter(vm *VM, x *Type) GFunction {
 func getter(vm *VM, x *Type) GFunction {
 
 	var (
-		mems = make(map[string]*Function)
+		mems      = make(map[string]*Function)
+		members   = make(map[string]GFunction)
+		described = make(map[string]string)
+		state     = loadState(vm)
 	)
 
-	members := memberFunctions(x.Type, func(v R.Value, m int, i *Invoker) {
-
-		i.Caller = func(vm *VM) (R.Value, error) {
+	if static, ok := R.Zero(x.Type).Interface().(StaticMethods); ok && !x.Reflect {
+		for name, method := range static.LuaMethods() {
 
 			var (
-				ov, err = x.checkValue(vm)
+				method = method
 			)
 
-			if err != nil {
-				return R.Value{}, err
-			}
+			members[name] = staticFunction(name, ReturnRaise, false, true, func(c *Call) int {
+
+				ov, err := x.checkValue(c.vm)
 
-			return ov.MethodByName(i.Name), nil
+				if err != nil {
+					c.vm.ArgError(1, err.Error())
+					return 0
+				}
+
+				return method(ov.Interface(), c)
+			})
 		}
 
-	})
+		described = signatures(static, x.Name+":")
+	} else {
+
+		var (
+			invokers = make(map[string]*Invoker)
+		)
+
+		members = memberFunctions(x.Type, func(v R.Value, m int, i *Invoker) {
+
+			invokers[i.Name] = i
+
+			i.Self = true
+			i.Caller = func(vm *VM) (R.Value, error) {
+
+				var (
+					ov, err = x.checkValue(vm)
+				)
+
+				if err != nil {
+					return R.Value{}, err
+				}
+
+				return ov.MethodByName(i.Name), nil
+			}
+
+		})
+
+		for name, i := range invokers {
+			described[name] = x.Name + ":" + i.Signature()
+		}
+	}
 
 	for name, member := range members {
 		mems[name] = vm.NewFunction(member)
+
+		if signature, ok := described[name]; ok {
+			state.describe(mems[name], signature)
+		}
 	}
 
 	i := &Invoker{
 		Name: "__index",
+		Self: true,
 		GoFunc: func(c *Call) int {
 
 			var (
@@ -149,7 +196,7 @@ func getter(vm *VM, x *Type) GFunction {
 			)
 
 			if err != nil {
-				c.ArgError(1, err.Error())
+				c.ArgError(1, "%s", err)
 				return 0
 			}
 
@@ -184,7 +231,7 @@ func getter(vm *VM, x *Type) GFunction {
 				value, err := encoder.Encode(field)
 
 				if err != nil {
-					c.ArgError(1, err.Error())
+					c.ArgError(1, "%s", err)
 				}
 
 				return c.Push(value)
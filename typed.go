@@ -24,16 +24,20 @@ func GoType(x interface{}) R.Type {
 	return R.TypeOf(x)
 }
 
-type Typed struct {
-	value Value
-}
-
-func (m *Typed) setValue(v Value) {
-	m.value = v
+// Finalizer is implemented by typed objects which release resources when
+// their userdata is collected. gopher-lua has no __gc, Finalize is called
+// once the go garbage collector reclaims the userdata, on a goroutine of its
+// own so it must not use the vm, or by Close for the objects still alive.
+// An object encoded in several vms is closed and finalized once, by the last
+// vm to drop it. Zero sized objects have no identity, they are neither.
+type Finalizer interface {
+	Finalize()
 }
 
-func (m *Typed) getValue() Value {
-	return m.value
+// Typed marks a struct as a lua type, its values are encoded as userdata. A
+// value keeps its userdata within a vm as long as lua holds it, each vm has
+// its own.
+type Typed struct {
 }
 
 func (m *Typed) class() {
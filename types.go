@@ -1,6 +1,7 @@
 package lua
 
 import (
+	"io"
 	R "reflect"
 
 	lua "github.com/yuin/gopher-lua"
@@ -27,8 +28,7 @@ type tableMapping interface {
 }
 
 type class interface {
-	setValue(value Value)
-	getValue() Value
+	class()
 }
 
 type moduleMembers interface {
@@ -40,11 +40,14 @@ func typedCaller(_ *Call) int { return 0 }
 var (
 	typeNil          = R.TypeOf(nil)
 	typeChannel      = R.TypeOf((lua.LChannel)(nil))
-	typeTableMapping = R.TypeOf((*tableMapping)(nil))
+	typeTableMapping = R.TypeOf((*tableMapping)(nil)).Elem()
 	typeClass        = R.TypeOf((*class)(nil)).Elem()
 	typeCall         = R.TypeOf((*Call)(nil))
 	typeCaller       = R.TypeOf(typedCaller)
 	typeValue        = R.TypeOf((*Value)(nil)).Elem()
+	typeCloser       = R.TypeOf((*io.Closer)(nil)).Elem()
+
+	typeModuleMembers = R.TypeOf((*moduleMembers)(nil)).Elem()
 
 	typeGFunc = R.TypeOf((GFunction)(nil))
 
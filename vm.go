@@ -6,6 +6,9 @@ import (
 
 type LGFunction func(vm *VM) int
 
+// New creates a vm, it must be closed with Close rather than vm.Close so the
+// modules members and typed objects it holds are closed as well. A vm closed
+// with vm.Close is logged and released only when it is collected.
 func New() *VM {
 	return lua.NewState(lua.Options{
 		IncludeGoStackTrace: true,